@@ -4,6 +4,8 @@ import (
 	"Bank/internal/config"
 	"Bank/internal/handler"
 	"Bank/internal/middleware"
+	"Bank/internal/model"
+	"Bank/internal/pan"
 	"Bank/internal/repository"
 	"Bank/internal/service"
 	"database/sql"
@@ -46,23 +48,31 @@ func runMigrations(dsn string, migrationsPath string) error {
 	return nil
 }
 
-func startScheduler(interval time.Duration, svc *service.CreditService) {
-	log.Println("Шедулер: первичный запуск обработки платежей…")
-	if err := svc.ProcessDuePayments(); err != nil {
-		log.Printf("Ошибка при первичной обработке платежей: %v", err)
-	} else {
-		log.Println("Первичная обработка завершена")
-	}
+// job — периодическая задача шедулера.
+type job struct {
+	name string
+	run  func() error
+}
+
+func startScheduler(interval time.Duration, jobs ...job) {
+	log.Println("Шедулер: первичный запуск фоновых задач…")
+	runScheduledJobs(jobs)
 
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	for range ticker.C {
-		log.Println("Шедулер: очередная проверка просроченных платежей…")
-		if err := svc.ProcessDuePayments(); err != nil {
-			log.Printf("Ошибка при обработке платежей: %v", err)
+		log.Println("Шедулер: очередной запуск фоновых задач…")
+		runScheduledJobs(jobs)
+	}
+}
+
+func runScheduledJobs(jobs []job) {
+	for _, j := range jobs {
+		if err := j.run(); err != nil {
+			log.Printf("Ошибка задачи «%s»: %v", j.name, err)
 		} else {
-			log.Println("Обработка завершена")
+			log.Printf("Задача «%s» завершена", j.name)
 		}
 	}
 }
@@ -79,16 +89,15 @@ func main() {
 		log.Fatalf("db open: %v", err)
 	}
 
-	userRepo := repository.NewUserRepository(db)
-	authSvc := service.NewAuthService(userRepo, cfg.JWTSecret)
-	authH := handler.NewAuthHandler(authSvc)
-
-	r := mux.NewRouter()
-	r.HandleFunc("/register", authH.Register).Methods("POST")
-	r.HandleFunc("/login", authH.Login).Methods("POST")
-
-	authRouter := r.PathPrefix("/").Subrouter()
-	authRouter.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+	keyMgr, err := service.NewKeyManager(
+		repository.NewEncryptionKeyRepository(db),
+		cfg.PGPPublicKey,
+		cfg.PGPPrivateKey,
+		cfg.PGPPrivateKeyPassphrase,
+	)
+	if err != nil {
+		log.Fatalf("Ключи шифрования: %v", err)
+	}
 
 	mailCfg := service.MailConfig{
 		Host:     cfg.SMTPHost,
@@ -98,30 +107,218 @@ func main() {
 		From:     cfg.SMTPUser,
 	}
 	mailSvc := service.NewMailService(mailCfg)
+
+	userRepo := repository.NewUserRepository(db)
+	throttleSvc := service.NewLoginThrottleService(
+		repository.NewLoginThrottleRepository(db),
+		userRepo,
+		mailSvc,
+		cfg.HMACSecret,
+		cfg.PublicBaseURL,
+	)
+	tfaSvc := service.NewTwoFactorService(
+		repository.NewTwoFactorRepository(db),
+		userRepo,
+		keyMgr,
+		throttleSvc,
+		cfg.BankName,
+		cfg.TwoFactorTransferThreshold,
+	)
+	sessionRepo := repository.NewSessionRepository(db)
+	authSvc := service.NewAuthService(
+		userRepo,
+		sessionRepo,
+		repository.NewPasswordResetRepository(db),
+		tfaSvc,
+		throttleSvc,
+		mailSvc,
+		cfg.JWTSecret,
+		cfg.HMACSecret,
+		cfg.PublicBaseURL,
+	)
+	authH := handler.NewAuthHandler(authSvc)
+	tfaH := handler.NewTwoFactorHandler(authSvc, tfaSvc)
+
+	r := mux.NewRouter()
+	r.HandleFunc("/register", authH.Register).Methods("POST")
+	r.HandleFunc("/login", authH.Login).Methods("POST")
+	r.HandleFunc("/login/unlock", authH.UnlockLogin).Methods("GET")
+	r.HandleFunc("/token/refresh", authH.Refresh).Methods("POST")
+	r.HandleFunc("/email/verify", authH.VerifyEmail).Methods("GET")
+	r.HandleFunc("/password/forgot", authH.ForgotPassword).Methods("POST")
+	r.HandleFunc("/password/reset", authH.ResetPassword).Methods("POST")
+
+	// эквайер авторизуется ключом API, а не JWT
+	acquirerRouter := r.PathPrefix("/acquiring").Subrouter()
+	acquirerRouter.Use(middleware.RequireAPIKey("X-API-Key", cfg.AcquirerAPIKey))
+
+	// хранилище токенов карт доступно только внутренним системам банка
+	vaultRouter := r.PathPrefix("/vault").Subrouter()
+	vaultRouter.Use(middleware.RequireAPIKey("X-API-Key", cfg.VaultAPIKey))
+
+	// до подтверждения email доступны только выход и повторная отправка письма
+	sessionRouter := r.PathPrefix("/").Subrouter()
+	sessionRouter.Use(middleware.AuthMiddleware(cfg.JWTSecret, authSvc))
+
+	sessionRouter.HandleFunc("/logout", authH.Logout).Methods("POST")
+	sessionRouter.HandleFunc("/logout/all", authH.LogoutAll).Methods("POST")
+	sessionRouter.HandleFunc("/email/verify/resend", authH.ResendVerification).Methods("POST")
+
+	authRouter := r.PathPrefix("/").Subrouter()
+	authRouter.Use(middleware.AuthMiddleware(cfg.JWTSecret, authSvc), middleware.RequireVerifiedEmail(authSvc))
+
+	authRouter.HandleFunc("/2fa", tfaH.Status).Methods("GET")
+	authRouter.HandleFunc("/2fa/totp", tfaH.Setup).Methods("POST")
+	authRouter.HandleFunc("/2fa/totp/confirm", tfaH.Confirm).Methods("POST")
+	authRouter.HandleFunc("/2fa/totp", tfaH.Disable).Methods("DELETE")
+	authRouter.HandleFunc("/2fa/recovery-codes", tfaH.RegenerateRecoveryCodes).Methods("POST")
+
 	accRepo := repository.NewAccountRepository(db)
 	txRepo := repository.NewTransactionRepository(db)
-	accSvc := service.NewAccountService(db, userRepo, accRepo, txRepo, mailSvc)
-	accH := handler.NewAccountHandler(accSvc)
+	feeRepo := repository.NewFeeRepository(db)
+	feeSvc := service.NewFeeService(feeRepo, txRepo)
+	feeH := handler.NewFeeHandler(feeSvc)
+	limitRepo := repository.NewLimitRepository(db)
+	limitSvc := service.NewLimitService(limitRepo, accRepo)
+	accSvc := service.NewAccountService(db, userRepo, accRepo, txRepo, mailSvc, feeSvc, limitSvc, tfaSvc)
+
+	aliasRepo := repository.NewPhoneAliasRepository(db)
+	confirmRepo := repository.NewTransferConfirmationRepository(db)
+	smsSvc := service.NewSMSService(cfg.SMSGatewayURL, cfg.SMSGatewayToken)
+	recipientSvc := service.NewRecipientService(userRepo, accRepo, aliasRepo, confirmRepo, accSvc, smsSvc)
+	recipientH := handler.NewRecipientHandler(recipientSvc)
+
+	payeeRepo := repository.NewPayeeRepository(db)
+	payeeSvc := service.NewPayeeService(payeeRepo, accRepo, recipientSvc, accSvc)
+	payeeH := handler.NewPayeeHandler(payeeSvc)
+	accH := handler.NewAccountHandler(accSvc, payeeSvc, limitSvc)
 
 	authRouter.HandleFunc("/accounts", accH.CreateAccount).Methods("POST")
 	authRouter.HandleFunc("/accounts", accH.ListAccounts).Methods("GET")
 	authRouter.HandleFunc("/accounts/deposit", accH.Deposit).Methods("POST")
 	authRouter.HandleFunc("/accounts/withdraw", accH.Withdraw).Methods("POST")
 	authRouter.HandleFunc("/transfer", accH.Transfer).Methods("POST")
+	authRouter.HandleFunc("/limits", accH.Limits).Methods("GET")
+	authRouter.HandleFunc("/limits", accH.UpdateLimit).Methods("PUT")
+	authRouter.HandleFunc("/fees", feeH.List).Methods("GET")
+	authRouter.HandleFunc("/fees/preview", feeH.Preview).Methods("GET")
+
+	authRouter.HandleFunc("/payees", payeeH.Create).Methods("POST")
+	authRouter.HandleFunc("/payees", payeeH.List).Methods("GET")
+	authRouter.HandleFunc("/payees/{payeeId}", payeeH.Get).Methods("GET")
+	authRouter.HandleFunc("/payees/{payeeId}", payeeH.Update).Methods("PUT")
+	authRouter.HandleFunc("/payees/{payeeId}", payeeH.Delete).Methods("DELETE")
+
+	authRouter.HandleFunc("/aliases/phone", recipientH.RegisterPhone).Methods("PUT")
+	authRouter.HandleFunc("/aliases/phone", recipientH.ListPhones).Methods("GET")
+	authRouter.HandleFunc("/aliases/phone/confirm", recipientH.ConfirmPhone).Methods("POST")
+	authRouter.HandleFunc("/aliases/phone/{phone}", recipientH.DeletePhone).Methods("DELETE")
+	authRouter.HandleFunc("/transfer/recipient", recipientH.Prepare).Methods("POST")
+	authRouter.HandleFunc("/transfer/recipient/confirm", recipientH.Confirm).Methods("POST")
+
+	qrSvc := service.NewQRService(accRepo, userRepo, cfg.BankName, cfg.BankBIC, cfg.BankCorrAcc)
+	qrH := handler.NewQRHandler(qrSvc)
+
+	authRouter.HandleFunc("/accounts/{accountId}/qr", qrH.Generate).Methods("GET")
+	authRouter.HandleFunc("/qr/parse", qrH.Parse).Methods("POST")
+
+	payReqRepo := repository.NewPaymentRequestRepository(db)
+	payReqSvc := service.NewPaymentRequestService(payReqRepo, accRepo, userRepo, accSvc, cfg.HMACSecret, cfg.PublicBaseURL)
+	payReqH := handler.NewPaymentRequestHandler(payReqSvc)
+
+	authRouter.HandleFunc("/payment-requests", payReqH.Create).Methods("POST")
+	authRouter.HandleFunc("/payment-requests", payReqH.List).Methods("GET")
+	authRouter.HandleFunc("/payment-requests/{requestId}/link", payReqH.Link).Methods("GET")
+	authRouter.HandleFunc("/payment-requests/{requestId}", payReqH.Cancel).Methods("DELETE")
+	authRouter.HandleFunc("/payment-links/{token}", payReqH.View).Methods("GET")
+	authRouter.HandleFunc("/payment-links/{token}/pay", payReqH.Pay).Methods("POST")
+
+	orderRepo := repository.NewStandingOrderRepository(db)
+	orderSvc := service.NewStandingOrderService(orderRepo, accRepo, accSvc, service.DefaultRetryPolicy)
+	orderH := handler.NewStandingOrderHandler(orderSvc)
+
+	authRouter.HandleFunc("/standing-orders", orderH.Create).Methods("POST")
+	authRouter.HandleFunc("/standing-orders", orderH.List).Methods("GET")
+	authRouter.HandleFunc("/standing-orders/{orderId}", orderH.Cancel).Methods("DELETE")
+	authRouter.HandleFunc("/standing-orders/{orderId}/executions", orderH.Executions).Methods("GET")
+
+	operatorOnly := middleware.RequireRole(model.RoleOperator, model.RoleRiskOfficer, model.RoleAdmin)
+	adminOnly := middleware.RequireRole(model.RoleAdmin)
+	reversalSvc := service.NewReversalService(db, userRepo, accRepo, txRepo, mailSvc)
+	disputeRepo := repository.NewDisputeRepository(db)
+	disputeSvc := service.NewDisputeService(disputeRepo, txRepo, accRepo, userRepo, reversalSvc, mailSvc)
+	disputeH := handler.NewDisputeHandler(disputeSvc, reversalSvc)
 
+	authRouter.HandleFunc("/transactions/{transactionId}/disputes", disputeH.Open).Methods("POST")
+	authRouter.HandleFunc("/disputes", disputeH.List).Methods("GET")
+	authRouter.HandleFunc("/disputes/{disputeId}", disputeH.Get).Methods("GET")
+	authRouter.Handle("/transactions/{transactionId}/reverse", operatorOnly(http.HandlerFunc(disputeH.Reverse))).Methods("POST")
+	authRouter.Handle("/operator/disputes", operatorOnly(http.HandlerFunc(disputeH.Queue))).Methods("GET")
+	authRouter.Handle("/disputes/{disputeId}/review", operatorOnly(http.HandlerFunc(disputeH.Review))).Methods("POST")
+	authRouter.Handle("/disputes/{disputeId}/resolve", operatorOnly(http.HandlerFunc(disputeH.Resolve))).Methods("POST")
+
+	holdRepo := repository.NewHoldRepository(db)
+	holdSvc := service.NewHoldService(db, holdRepo, accRepo, txRepo)
+	holdH := handler.NewHoldHandler(holdSvc)
+
+	authRouter.HandleFunc("/accounts/{accountId}/holds", holdH.Create).Methods("POST")
+	authRouter.HandleFunc("/accounts/{accountId}/holds", holdH.List).Methods("GET")
+	authRouter.HandleFunc("/holds/{holdId}/release", holdH.Release).Methods("POST")
+
+	auditSvc := service.NewAuditService(repository.NewAuditRepository(db))
+	binRanges, err := pan.ParseRanges(cfg.CardBINRanges)
+	if err != nil {
+		log.Fatalf("CARD_BIN_RANGES: %v", err)
+	}
 	cardRepo := repository.NewCardRepository(db)
+	cardTxRepo := repository.NewCardTransactionRepository(db)
 	cardSvc := service.NewCardService(
-		cfg.PGPPublicKey,
-		cfg.PGPPrivateKey,
-		cfg.PGPPrivateKeyPassphrase,
+		db,
+		keyMgr,
 		cfg.HMACSecret,
+		binRanges,
 		cardRepo,
+		cardTxRepo,
 		accRepo,
+		feeSvc,
+		authSvc,
+		auditSvc,
 	)
 	cardH := handler.NewCardHandler(cardSvc)
 
 	authRouter.HandleFunc("/cards", cardH.Create).Methods("POST")
 	authRouter.HandleFunc("/cards", cardH.List).Methods("GET")
+	authRouter.HandleFunc("/cards/virtual", cardH.CreateVirtual).Methods("POST")
+	authRouter.HandleFunc("/cards/{cardId}/reveal", cardH.Reveal).Methods("POST")
+	authRouter.HandleFunc("/cards/{cardId}/pin", cardH.SetPIN).Methods("PUT")
+	authRouter.HandleFunc("/cards/{cardId}/pin/change", cardH.ChangePIN).Methods("POST")
+	authRouter.HandleFunc("/cards/{cardId}/transactions", cardH.Transactions).Methods("GET")
+	authRouter.HandleFunc("/cards/{cardId}/controls", cardH.Controls).Methods("GET")
+	authRouter.HandleFunc("/cards/{cardId}/controls", cardH.UpdateControls).Methods("PUT")
+	authRouter.HandleFunc("/cards/{cardId}/block", cardH.Block).Methods("POST")
+	authRouter.HandleFunc("/cards/{cardId}/unblock", cardH.Unblock).Methods("POST")
+	authRouter.HandleFunc("/cards/{cardId}/reissue", cardH.Reissue).Methods("POST")
+	authRouter.Handle("/operator/cards/{cardId}/block", operatorOnly(http.HandlerFunc(cardH.BankBlock))).Methods("POST")
+	authRouter.Handle("/operator/cards/{cardId}/unblock", operatorOnly(http.HandlerFunc(cardH.BankUnblock))).Methods("POST")
+	authRouter.Handle("/operator/keys/rotate", adminOnly(http.HandlerFunc(cardH.RotateKey))).Methods("POST")
+
+	cardTransferH := handler.NewCardTransferHandler(service.NewCardTransferService(cardSvc, accSvc))
+
+	authRouter.HandleFunc("/transfers/card", cardTransferH.Transfer).Methods("POST")
+
+	tokenSvc := service.NewTokenService(repository.NewCardTokenRepository(db), cardRepo, cardSvc, auditSvc)
+	tokenH := handler.NewTokenHandler(tokenSvc)
+
+	authRouter.HandleFunc("/cards/{cardId}/tokens", tokenH.Create).Methods("POST")
+	authRouter.HandleFunc("/cards/{cardId}/tokens", tokenH.List).Methods("GET")
+	authRouter.HandleFunc("/cards/{cardId}/tokens/{tokenId}", tokenH.Delete).Methods("DELETE")
+	vaultRouter.HandleFunc("/tokens", tokenH.Tokenize).Methods("POST")
+	vaultRouter.HandleFunc("/detokenize", tokenH.Detokenize).Methods("POST")
+
+	acquiringSvc := service.NewAcquiringService(db, cardSvc, holdSvc, limitSvc, cardTxRepo)
+	acquiringH := handler.NewAcquiringHandler(acquiringSvc)
+
+	acquirerRouter.HandleFunc("/messages", acquiringH.Message).Methods("POST")
 
 	cbrSvc := service.NewCBRService()
 	creditRepo := repository.NewCreditRepository(db)
@@ -138,7 +335,25 @@ func main() {
 	authRouter.HandleFunc("/analytics", analyticsH.GetStats).Methods("GET")
 	authRouter.HandleFunc("/accounts/{accountId}/predict", analyticsH.Predict).Methods("GET")
 
-	go startScheduler(5*time.Hour, creditSvc)
+	staffSvc := service.NewStaffService(userRepo, sessionRepo, accSvc, creditSvc, cardSvc, auditSvc)
+	staffSvc.BootstrapRoles(cfg.OperatorIDs, model.RoleOperator)
+	staffSvc.BootstrapRoles(cfg.AdminIDs, model.RoleAdmin)
+	staffH := handler.NewStaffHandler(staffSvc)
+
+	authRouter.Handle("/operator/users/{userId}/accounts", operatorOnly(http.HandlerFunc(staffH.UserAccounts))).Methods("GET")
+	authRouter.Handle("/operator/users/{userId}/credits", operatorOnly(http.HandlerFunc(staffH.UserCredits))).Methods("GET")
+	authRouter.Handle("/operator/users/{userId}/cards", operatorOnly(http.HandlerFunc(staffH.UserCards))).Methods("GET")
+	authRouter.Handle("/admin/users/{userId}/role", adminOnly(http.HandlerFunc(staffH.SetRole))).Methods("PUT")
+
+	go startScheduler(5*time.Hour,
+		job{"обработка платежей по кредитам", creditSvc.ProcessDuePayments},
+		job{"исполнение поручений", orderSvc.ProcessDueOrders},
+		job{"истечение холдов", holdSvc.ExpireHolds},
+		job{"истечение срока действия карт", cardSvc.ExpireCards},
+		job{"перешифрование карт", cardSvc.ReencryptCards},
+		job{"очистка сессий", authSvc.PurgeSessions},
+		job{"очистка счётчиков неудачных входов", throttleSvc.Purge},
+	)
 	log.Println("Server is running on :8080")
 
 	log.Fatal(http.ListenAndServe(":8080", r))
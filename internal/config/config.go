@@ -5,8 +5,12 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 )
 
+// defaultCardBINRanges — продукты по умолчанию: Мир и тестовые диапазоны Visa и Mastercard.
+const defaultCardBINRanges = "mir_classic=MIR:2200-2204,visa_classic=VISA:400000-400099,mc_standard=MASTERCARD:510000-510099"
+
 type Config struct {
 	DBHost, DBPort, DBUser, DBPass, DBName               string
 	JWTSecret                                            string
@@ -14,6 +18,14 @@ type Config struct {
 	SMTPPort                                             int
 	PGPPrivateKey, PGPPublicKey, PGPPrivateKeyPassphrase string
 	HMACSecret                                           string
+	PublicBaseURL                                        string
+	BankName, BankBIC, BankCorrAcc                       string
+	OperatorIDs, AdminIDs                                []int
+	AcquirerAPIKey                                       string
+	CardBINRanges                                        string
+	VaultAPIKey                                          string
+	TwoFactorTransferThreshold                           float64
+	SMSGatewayURL, SMSGatewayToken                       string
 }
 
 func Load() *Config {
@@ -35,6 +47,19 @@ func Load() *Config {
 		PGPPrivateKey:           os.Getenv("PGP_PRIVATE_KEY"),
 		PGPPublicKey:            os.Getenv("PGP_PUBLIC_KEY"),
 		PGPPrivateKeyPassphrase: os.Getenv("PGP_PASSPHRASE"),
+		PublicBaseURL:           stringOrDefault(os.Getenv("PUBLIC_BASE_URL"), "http://localhost:8080"),
+		BankName:                stringOrDefault(os.Getenv("BANK_NAME"), "Bank"),
+		BankBIC:                 stringOrDefault(os.Getenv("BANK_BIC"), "044525000"),
+		BankCorrAcc:             stringOrDefault(os.Getenv("BANK_CORR_ACC"), "30101810000000000000"),
+		OperatorIDs:             parseIDs(os.Getenv("OPERATOR_IDS")),
+		AdminIDs:                parseIDs(os.Getenv("ADMIN_IDS")),
+		AcquirerAPIKey:          os.Getenv("ACQUIRER_API_KEY"),
+		CardBINRanges:           stringOrDefault(os.Getenv("CARD_BIN_RANGES"), defaultCardBINRanges),
+		VaultAPIKey:             os.Getenv("VAULT_API_KEY"),
+		SMSGatewayURL:           os.Getenv("SMS_GATEWAY_URL"),
+		SMSGatewayToken:         os.Getenv("SMS_GATEWAY_TOKEN"),
+		// 0 — второй фактор для переводов не требуется
+		TwoFactorTransferThreshold: floatOrDefault(os.Getenv("TWO_FACTOR_TRANSFER_THRESHOLD"), 0),
 	}
 }
 
@@ -44,3 +69,28 @@ func atoiOrDefault(s string, def int) int {
 	}
 	return def
 }
+
+func floatOrDefault(s string, def float64) float64 {
+	if v, err := strconv.ParseFloat(s, 64); err == nil {
+		return v
+	}
+	return def
+}
+
+func stringOrDefault(s, def string) string {
+	if s != "" {
+		return s
+	}
+	return def
+}
+
+// parseIDs разбирает список идентификаторов через запятую, пропуская некорректные.
+func parseIDs(s string) []int {
+	var ids []int
+	for _, part := range strings.Split(s, ",") {
+		if id, err := strconv.Atoi(strings.TrimSpace(part)); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
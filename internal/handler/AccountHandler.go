@@ -3,8 +3,10 @@ package handler
 import (
 	"Bank/internal/middleware"
 	"Bank/internal/model"
+	"Bank/internal/repository"
 	"Bank/internal/service"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -12,11 +14,13 @@ import (
 )
 
 type AccountHandler struct {
-	accSvc *service.AccountService
+	accSvc   *service.AccountService
+	payeeSvc *service.PayeeService
+	limitSvc *service.LimitService
 }
 
-func NewAccountHandler(s *service.AccountService) *AccountHandler {
-	return &AccountHandler{accSvc: s}
+func NewAccountHandler(s *service.AccountService, ps *service.PayeeService, ls *service.LimitService) *AccountHandler {
+	return &AccountHandler{accSvc: s, payeeSvc: ps, limitSvc: ls}
 }
 
 func (h *AccountHandler) CreateAccount(w http.ResponseWriter, r *http.Request) {
@@ -96,25 +100,38 @@ func (h *AccountHandler) Withdraw(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tx, err := h.accSvc.Withdraw(userID, req.AccountID, req.Amount)
+	tx, feeTx, err := h.accSvc.Withdraw(userID, req.AccountID, req.Amount)
 	if err != nil {
 		code := http.StatusInternalServerError
-		switch err {
-		case service.ErrAccessDenied:
+		switch {
+		case errors.Is(err, service.ErrAccessDenied):
 			code = http.StatusForbidden
-		case service.ErrInsufficientFunds:
+		case errors.Is(err, service.ErrInsufficientFunds):
 			code = http.StatusConflict
+		case errors.Is(err, service.ErrLimitExceeded):
+			code = http.StatusUnprocessableEntity
 		}
 		http.Error(w, err.Error(), code)
 		return
 	}
-	json.NewEncoder(w).Encode(tx)
+	json.NewEncoder(w).Encode(withdrawResponse{Transaction: tx, Fee: feeTx})
+}
+
+// withdrawResponse — операция снятия в прежнем виде; fee появляется,
+// только если взималась комиссия.
+type withdrawResponse struct {
+	*model.Transaction
+	Fee *model.Transaction `json:"fee,omitempty"`
 }
 
+// TransferRequest принимает либо to_account_id, либо payee_id сохранённого
+// получателя; для получателя с суммой по умолчанию amount можно не указывать.
 type TransferRequest struct {
 	FromAccountID int     `json:"from_account_id" validate:"required"`
-	ToAccountID   int     `json:"to_account_id"   validate:"required"`
-	Amount        float64 `json:"amount"          validate:"required,gt=0"`
+	ToAccountID   int     `json:"to_account_id"   validate:"required_without=PayeeID,excluded_with=PayeeID"`
+	PayeeID       int     `json:"payee_id"`
+	Amount        float64 `json:"amount"          validate:"required_without=PayeeID,omitempty,gt=0"`
+	OTP           string  `json:"otp"             validate:"max=20"`
 }
 
 func (tr *TransferRequest) Validate() error { return model.ValidateStruct(tr) }
@@ -132,20 +149,73 @@ func (h *AccountHandler) Transfer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	txFrom, txTo, err := h.accSvc.Transfer(userID, req.FromAccountID, req.ToAccountID, req.Amount)
+	var res *model.TransferResult
+	var err error
+	if req.PayeeID != 0 {
+		res, err = h.payeeSvc.Transfer(userID, req.PayeeID, req.FromAccountID, req.Amount, req.OTP)
+	} else {
+		res, err = h.accSvc.UserTransfer(userID, req.FromAccountID, req.ToAccountID, req.Amount, req.OTP)
+	}
 	if err != nil {
 		code := http.StatusInternalServerError
-		switch err {
-		case service.ErrAccessDenied:
+		switch {
+		case errors.Is(err, service.ErrAccessDenied), errors.Is(err, service.ErrPayeeNotYours),
+			errors.Is(err, service.ErrTwoFactorRequired), errors.Is(err, service.ErrOTPRequired),
+			errors.Is(err, service.ErrInvalidOTP):
 			code = http.StatusForbidden
-		case service.ErrInsufficientFunds:
+		case errors.Is(err, service.ErrTooManyVerifications):
+			code = http.StatusTooManyRequests
+		case errors.Is(err, service.ErrInsufficientFunds):
 			code = http.StatusConflict
+		case errors.Is(err, service.ErrLimitExceeded), errors.Is(err, service.ErrSameAccount):
+			code = http.StatusUnprocessableEntity
+		case errors.Is(err, service.ErrAmountRequired):
+			code = http.StatusBadRequest
+		case errors.Is(err, repository.ErrPayeeNotFound), errors.Is(err, service.ErrRecipientNotFound):
+			code = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *AccountHandler) Limits(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+	list, err := h.limitSvc.Status(userID)
+	if err != nil {
+		http.Error(w, "cannot fetch limits", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(list)
+}
+
+func (h *AccountHandler) UpdateLimit(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+
+	var req model.TransactionLimitUpdate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	limit, err := h.limitSvc.Update(userID, &req)
+	if err != nil {
+		code := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrAccessDenied):
+			code = http.StatusForbidden
+		case errors.Is(err, repository.ErrAccountNotFound):
+			code = http.StatusNotFound
+		case errors.Is(err, service.ErrLimitAboveMaximum), errors.Is(err, service.ErrUnsupportedLimitOp):
+			code = http.StatusBadRequest
 		}
 		http.Error(w, err.Error(), code)
 		return
 	}
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"debit":  txFrom,
-		"credit": txTo,
-	})
+	json.NewEncoder(w).Encode(limit)
 }
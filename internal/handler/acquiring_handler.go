@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"Bank/internal/model"
+	"Bank/internal/service"
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+type AcquiringHandler struct {
+	acquiringSvc *service.AcquiringService
+}
+
+func NewAcquiringHandler(s *service.AcquiringService) *AcquiringHandler {
+	return &AcquiringHandler{acquiringSvc: s}
+}
+
+// Message принимает сообщение эквайера. Отказ по карте — это ответ 200
+// с кодом DE39, HTTP-ошибки означают некорректное сообщение или сбой банка.
+func (h *AcquiringHandler) Message(w http.ResponseWriter, r *http.Request) {
+	var msg model.AcquirerMessage
+	if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	if err := msg.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.acquiringSvc.Handle(&msg)
+	if err != nil {
+		log.Printf("Ошибка обработки сообщения %s (RRN %s): %v", msg.MTI, msg.RRN, err)
+		resp = &model.AcquirerResponse{MTI: msg.MTI, STAN: msg.STAN, RRN: msg.RRN, ResponseCode: model.RespSystemError}
+	}
+	json.NewEncoder(w).Encode(resp)
+}
@@ -1,12 +1,15 @@
 package handler
 
 import (
+	"Bank/internal/middleware"
 	"Bank/internal/model"
 	"Bank/internal/repository"
 	"Bank/internal/service"
 	"encoding/json"
 	"errors"
+	"math"
 	"net/http"
+	"strconv"
 )
 
 type AuthHandler struct {
@@ -53,11 +56,161 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := h.authSvc.Login(&req)
+	pair, err := h.authSvc.Login(&req, clientIP(r), r.UserAgent())
 	if err != nil {
-		http.Error(w, "invalid credentials", http.StatusUnauthorized)
+		var delay *service.LoginDelayError
+		switch {
+		case errors.As(err, &delay):
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(delay.RetryAfter.Seconds()))))
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+		case errors.Is(err, service.ErrInvalidCredentials):
+			http.Error(w, "invalid credentials", http.StatusUnauthorized)
+		case errors.Is(err, service.ErrOTPRequired), errors.Is(err, service.ErrInvalidOTP):
+			// пароль верен, клиент должен повторить вход с кодом
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+		default:
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(pair)
+}
+
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req model.TokenRefresh
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	pair, err := h.authSvc.Refresh(req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRefresh) {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+		} else {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(pair)
+}
+
+// Logout завершает текущую сессию.
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	sessionID, _ := r.Context().Value(middleware.SessionIDKey).(string)
+	if err := h.authSvc.Logout(sessionID); err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
 		return
 	}
+	w.WriteHeader(http.StatusNoContent)
+}
 
-	json.NewEncoder(w).Encode(map[string]string{"token": token})
+// LogoutAll завершает все сессии пользователя, включая текущую.
+func (h *AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+	n, err := h.authSvc.LogoutAll(userID)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]int64{"revoked_sessions": n})
+}
+
+// VerifyEmail подтверждает email по ссылке из письма.
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		http.Error(w, "token required", http.StatusBadRequest)
+		return
+	}
+	if err := h.authSvc.VerifyEmail(token); err != nil {
+		if errors.Is(err, service.ErrInvalidVerification) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		} else {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		}
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]bool{"email_verified": true})
+}
+
+// ResendVerification повторно отправляет письмо подтверждения email.
+func (h *AuthHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+	if err := h.authSvc.ResendVerification(userID); err != nil {
+		if errors.Is(err, service.ErrEmailAlreadyVerified) {
+			http.Error(w, err.Error(), http.StatusConflict)
+		} else {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// ForgotPassword всегда отвечает 202, даже если адрес не зарегистрирован.
+func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req model.ForgotPassword
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.authSvc.ForgotPassword(req.Email); err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req model.ResetPassword
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.authSvc.ResetPassword(&req); err != nil {
+		if errors.Is(err, repository.ErrResetTokenInvalid) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		} else {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UnlockLogin снимает блокировку входа по ссылке из письма.
+func (h *AuthHandler) UnlockLogin(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		http.Error(w, "token required", http.StatusBadRequest)
+		return
+	}
+	if err := h.authSvc.UnlockLogin(token); err != nil {
+		if errors.Is(err, service.ErrInvalidUnlockToken) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		} else {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		}
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]bool{"unlocked": true})
 }
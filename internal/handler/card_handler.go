@@ -2,10 +2,15 @@ package handler
 
 import (
 	"Bank/internal/middleware"
+	"Bank/internal/model"
+	"Bank/internal/repository"
 	"Bank/internal/service"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
+
+	"github.com/gorilla/mux"
 )
 
 type CardHandler struct {
@@ -24,9 +29,13 @@ func (h *CardHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	card, err := h.cardSvc.GenerateCard(userID, accountID)
+	card, err := h.cardSvc.GenerateCard(userID, accountID, r.URL.Query().Get("product"))
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		code := http.StatusBadRequest
+		if err == service.ErrInsufficientFunds {
+			code = http.StatusConflict
+		}
+		http.Error(w, err.Error(), code)
 		return
 	}
 
@@ -45,3 +54,261 @@ func (h *CardHandler) List(w http.ResponseWriter, r *http.Request) {
 	}
 	json.NewEncoder(w).Encode(cards)
 }
+
+func (h *CardHandler) CreateVirtual(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+
+	var req model.VirtualCardCreate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	card, err := h.cardSvc.IssueVirtual(userID, &req)
+	if err != nil {
+		http.Error(w, err.Error(), cardErrorCode(err))
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(card)
+}
+
+// Reveal возвращает полные реквизиты карты после повторного ввода пароля.
+func (h *CardHandler) Reveal(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+	cardID, err := strconv.Atoi(mux.Vars(r)["cardId"])
+	if err != nil {
+		http.Error(w, "invalid card id", http.StatusBadRequest)
+		return
+	}
+
+	var req model.StepUp
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	card, err := h.cardSvc.Reveal(userID, cardID, &req, clientIP(r))
+	if err != nil {
+		http.Error(w, err.Error(), cardErrorCode(err))
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(card)
+}
+
+// SetPIN устанавливает или сбрасывает PIN (требует пароль).
+func (h *CardHandler) SetPIN(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+	cardID, err := strconv.Atoi(mux.Vars(r)["cardId"])
+	if err != nil {
+		http.Error(w, "invalid card id", http.StatusBadRequest)
+		return
+	}
+
+	var req model.CardPINSet
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.cardSvc.SetPIN(userID, cardID, &req, clientIP(r)); err != nil {
+		http.Error(w, err.Error(), cardErrorCode(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *CardHandler) ChangePIN(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+	cardID, err := strconv.Atoi(mux.Vars(r)["cardId"])
+	if err != nil {
+		http.Error(w, "invalid card id", http.StatusBadRequest)
+		return
+	}
+
+	var req model.CardPINChange
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.cardSvc.ChangePIN(userID, cardID, &req); err != nil {
+		http.Error(w, err.Error(), cardErrorCode(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *CardHandler) Controls(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+	cardID, err := strconv.Atoi(mux.Vars(r)["cardId"])
+	if err != nil {
+		http.Error(w, "invalid card id", http.StatusBadRequest)
+		return
+	}
+
+	ctl, err := h.cardSvc.Controls(userID, cardID)
+	if err != nil {
+		http.Error(w, err.Error(), cardErrorCode(err))
+		return
+	}
+	json.NewEncoder(w).Encode(ctl)
+}
+
+func (h *CardHandler) UpdateControls(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+	cardID, err := strconv.Atoi(mux.Vars(r)["cardId"])
+	if err != nil {
+		http.Error(w, "invalid card id", http.StatusBadRequest)
+		return
+	}
+
+	var req model.CardControls
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctl, err := h.cardSvc.UpdateControls(userID, cardID, &req)
+	if err != nil {
+		http.Error(w, err.Error(), cardErrorCode(err))
+		return
+	}
+	json.NewEncoder(w).Encode(ctl)
+}
+
+func (h *CardHandler) Block(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, h.cardSvc.Block)
+}
+
+func (h *CardHandler) Unblock(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, h.cardSvc.Unblock)
+}
+
+// BankBlock и BankUnblock доступны только операторам.
+func (h *CardHandler) BankBlock(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, h.cardSvc.BankBlock)
+}
+
+func (h *CardHandler) BankUnblock(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, h.cardSvc.BankUnblock)
+}
+
+// RotateKey — ротация ключа шифрования реквизитов карт (только оператор).
+func (h *CardHandler) RotateKey(w http.ResponseWriter, r *http.Request) {
+	rotation, err := h.cardSvc.RotateKey()
+	if err != nil {
+		http.Error(w, "cannot rotate key", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(rotation)
+}
+
+func (h *CardHandler) Transactions(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+	cardID, err := strconv.Atoi(mux.Vars(r)["cardId"])
+	if err != nil {
+		http.Error(w, "invalid card id", http.StatusBadRequest)
+		return
+	}
+	limit, offset, err := pageParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	list, err := h.cardSvc.Transactions(userID, cardID, limit, offset)
+	if err != nil {
+		http.Error(w, err.Error(), cardErrorCode(err))
+		return
+	}
+	json.NewEncoder(w).Encode(list)
+}
+
+func (h *CardHandler) Reissue(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+	cardID, err := strconv.Atoi(mux.Vars(r)["cardId"])
+	if err != nil {
+		http.Error(w, "invalid card id", http.StatusBadRequest)
+		return
+	}
+
+	card, err := h.cardSvc.Reissue(userID, cardID)
+	if err != nil {
+		http.Error(w, err.Error(), cardErrorCode(err))
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"card_id": card.ID,
+	})
+}
+
+func (h *CardHandler) changeStatus(w http.ResponseWriter, r *http.Request, change func(userID, cardID int) (*model.Card, error)) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+	cardID, err := strconv.Atoi(mux.Vars(r)["cardId"])
+	if err != nil {
+		http.Error(w, "invalid card id", http.StatusBadRequest)
+		return
+	}
+
+	card, err := change(userID, cardID)
+	if err != nil {
+		http.Error(w, err.Error(), cardErrorCode(err))
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"card_id": card.ID,
+		"status":  card.Status,
+	})
+}
+
+func cardErrorCode(err error) int {
+	switch {
+	case errors.Is(err, service.ErrCardNotYours):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrTooManyVerifications):
+		return http.StatusTooManyRequests
+	case errors.Is(err, service.ErrStepUpFailed),
+		errors.Is(err, service.ErrInvalidPIN):
+		return http.StatusUnauthorized
+	case errors.Is(err, service.ErrWeakPIN),
+		errors.Is(err, service.ErrUnknownProduct),
+		errors.Is(err, service.ErrNotReissuable):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, service.ErrTooManyAttempts):
+		return http.StatusTooManyRequests
+	case errors.Is(err, repository.ErrCardNotFound),
+		errors.Is(err, repository.ErrAccountNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repository.ErrCardStatus),
+		errors.Is(err, service.ErrPINBlocked),
+		errors.Is(err, service.ErrPINNotSet),
+		errors.Is(err, service.ErrInsufficientFunds):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
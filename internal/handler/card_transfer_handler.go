@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"Bank/internal/middleware"
+	"Bank/internal/model"
+	"Bank/internal/repository"
+	"Bank/internal/service"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type CardTransferHandler struct {
+	transferSvc *service.CardTransferService
+}
+
+func NewCardTransferHandler(s *service.CardTransferService) *CardTransferHandler {
+	return &CardTransferHandler{transferSvc: s}
+}
+
+func (h *CardTransferHandler) Transfer(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+
+	var req model.CardTransferCreate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	// номер часто вводят группами по 4 цифры
+	req.ToCard = strings.ReplaceAll(req.ToCard, " ", "")
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	res, err := h.transferSvc.Transfer(userID, &req)
+	if err != nil {
+		http.Error(w, err.Error(), cardTransferErrorCode(err))
+		return
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+func cardTransferErrorCode(err error) int {
+	switch {
+	case errors.Is(err, service.ErrAccessDenied),
+		errors.Is(err, service.ErrTwoFactorRequired),
+		errors.Is(err, service.ErrOTPRequired),
+		errors.Is(err, service.ErrInvalidOTP):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrTooManyVerifications):
+		return http.StatusTooManyRequests
+	case errors.Is(err, service.ErrRecipientCardNotFound),
+		errors.Is(err, repository.ErrAccountNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrRecipientCardInactive),
+		errors.Is(err, service.ErrInsufficientFunds):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrInvalidCardNumber),
+		errors.Is(err, service.ErrCardOnSourceAccount),
+		errors.Is(err, service.ErrSameAccount),
+		errors.Is(err, service.ErrLimitExceeded):
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,15 @@
+package handler
+
+import (
+	"net"
+	"net/http"
+)
+
+// clientIP — адрес клиента для журнала аудита.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
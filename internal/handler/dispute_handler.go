@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"Bank/internal/middleware"
+	"Bank/internal/model"
+	"Bank/internal/repository"
+	"Bank/internal/service"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type DisputeHandler struct {
+	disputeSvc  *service.DisputeService
+	reversalSvc *service.ReversalService
+}
+
+func NewDisputeHandler(ds *service.DisputeService, rs *service.ReversalService) *DisputeHandler {
+	return &DisputeHandler{disputeSvc: ds, reversalSvc: rs}
+}
+
+func (h *DisputeHandler) Open(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+	transactionID, err := strconv.Atoi(mux.Vars(r)["transactionId"])
+	if err != nil {
+		http.Error(w, "invalid transaction id", http.StatusBadRequest)
+		return
+	}
+
+	var req model.DisputeCreate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	d, err := h.disputeSvc.Open(userID, transactionID, &req)
+	if err != nil {
+		http.Error(w, err.Error(), disputeErrorCode(err))
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(d)
+}
+
+func (h *DisputeHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+	list, err := h.disputeSvc.List(userID)
+	if err != nil {
+		http.Error(w, "cannot fetch disputes", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(list)
+}
+
+func (h *DisputeHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+	disputeID, err := strconv.Atoi(mux.Vars(r)["disputeId"])
+	if err != nil {
+		http.Error(w, "invalid dispute id", http.StatusBadRequest)
+		return
+	}
+
+	d, err := h.disputeSvc.Get(userID, disputeID)
+	if err != nil {
+		http.Error(w, err.Error(), disputeErrorCode(err))
+		return
+	}
+	json.NewEncoder(w).Encode(d)
+}
+
+// Queue — очередь споров для операторов (query: ?status=).
+func (h *DisputeHandler) Queue(w http.ResponseWriter, r *http.Request) {
+	list, err := h.disputeSvc.Queue(r.URL.Query().Get("status"))
+	if err != nil {
+		http.Error(w, "cannot fetch disputes", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(list)
+}
+
+func (h *DisputeHandler) Review(w http.ResponseWriter, r *http.Request) {
+	operatorID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+	disputeID, err := strconv.Atoi(mux.Vars(r)["disputeId"])
+	if err != nil {
+		http.Error(w, "invalid dispute id", http.StatusBadRequest)
+		return
+	}
+
+	d, err := h.disputeSvc.Review(operatorID, disputeID)
+	if err != nil {
+		http.Error(w, err.Error(), disputeErrorCode(err))
+		return
+	}
+	json.NewEncoder(w).Encode(d)
+}
+
+func (h *DisputeHandler) Resolve(w http.ResponseWriter, r *http.Request) {
+	operatorID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+	disputeID, err := strconv.Atoi(mux.Vars(r)["disputeId"])
+	if err != nil {
+		http.Error(w, "invalid dispute id", http.StatusBadRequest)
+		return
+	}
+
+	var req model.DisputeResolve
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	d, err := h.disputeSvc.Resolve(operatorID, disputeID, &req)
+	if err != nil {
+		http.Error(w, err.Error(), disputeErrorCode(err))
+		return
+	}
+	json.NewEncoder(w).Encode(d)
+}
+
+// Reverse — отмена операции оператором.
+func (h *DisputeHandler) Reverse(w http.ResponseWriter, r *http.Request) {
+	operatorID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+	transactionID, err := strconv.Atoi(mux.Vars(r)["transactionId"])
+	if err != nil {
+		http.Error(w, "invalid transaction id", http.StatusBadRequest)
+		return
+	}
+
+	var req model.ReversalRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	reversals, err := h.reversalSvc.Reverse(operatorID, transactionID, req.Reason)
+	if err != nil {
+		http.Error(w, err.Error(), disputeErrorCode(err))
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(reversals)
+}
+
+func disputeErrorCode(err error) int {
+	switch {
+	case errors.Is(err, service.ErrAccessDenied):
+		return http.StatusForbidden
+	case errors.Is(err, repository.ErrDisputeNotFound),
+		errors.Is(err, repository.ErrTransactionNotFound),
+		errors.Is(err, repository.ErrAccountNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repository.ErrDisputeAlreadyOpen),
+		errors.Is(err, repository.ErrDisputeStatusChanged),
+		errors.Is(err, repository.ErrAlreadyReversed),
+		errors.Is(err, service.ErrInsufficientFunds):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrNotReversible),
+		errors.Is(err, service.ErrNotDisputable):
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"Bank/internal/middleware"
+	"Bank/internal/model"
+	"Bank/internal/service"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+type FeeHandler struct {
+	feeSvc *service.FeeService
+}
+
+func NewFeeHandler(s *service.FeeService) *FeeHandler {
+	return &FeeHandler{feeSvc: s}
+}
+
+func (h *FeeHandler) List(w http.ResponseWriter, r *http.Request) {
+	list, err := h.feeSvc.Schedules()
+	if err != nil {
+		http.Error(w, "cannot fetch fee schedules", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(list)
+}
+
+// Preview показывает комиссию до выполнения операции:
+// GET /fees/preview?operation=withdraw&amount=1000
+func (h *FeeHandler) Preview(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+
+	op := r.URL.Query().Get("operation")
+	switch op {
+	case model.FeeOpWithdraw, model.FeeOpTransfer, model.FeeOpFX, model.FeeOpCardIssue:
+	default:
+		http.Error(w, "unknown operation", http.StatusBadRequest)
+		return
+	}
+	amount, err := strconv.ParseFloat(r.URL.Query().Get("amount"), 64)
+	if err != nil || amount < 0 {
+		amount = 0
+	}
+
+	quote, err := h.feeSvc.Quote(userID, op, amount)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(quote)
+}
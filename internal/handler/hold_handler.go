@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"Bank/internal/middleware"
+	"Bank/internal/model"
+	"Bank/internal/repository"
+	"Bank/internal/service"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type HoldHandler struct {
+	holdSvc *service.HoldService
+}
+
+func NewHoldHandler(s *service.HoldService) *HoldHandler {
+	return &HoldHandler{holdSvc: s}
+}
+
+func (h *HoldHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+	accountID, err := strconv.Atoi(mux.Vars(r)["accountId"])
+	if err != nil {
+		http.Error(w, "invalid account id", http.StatusBadRequest)
+		return
+	}
+
+	var req model.HoldCreate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	hold, err := h.holdSvc.Create(userID, accountID, &req)
+	if err != nil {
+		http.Error(w, err.Error(), holdErrorCode(err))
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(hold)
+}
+
+func (h *HoldHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+	accountID, err := strconv.Atoi(mux.Vars(r)["accountId"])
+	if err != nil {
+		http.Error(w, "invalid account id", http.StatusBadRequest)
+		return
+	}
+
+	list, err := h.holdSvc.List(userID, accountID)
+	if err != nil {
+		http.Error(w, err.Error(), holdErrorCode(err))
+		return
+	}
+	json.NewEncoder(w).Encode(list)
+}
+
+func (h *HoldHandler) Release(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+	holdID, err := strconv.Atoi(mux.Vars(r)["holdId"])
+	if err != nil {
+		http.Error(w, "invalid hold id", http.StatusBadRequest)
+		return
+	}
+
+	hold, err := h.holdSvc.ReleaseOwned(userID, holdID)
+	if err != nil {
+		http.Error(w, err.Error(), holdErrorCode(err))
+		return
+	}
+	json.NewEncoder(w).Encode(hold)
+}
+
+func holdErrorCode(err error) int {
+	switch {
+	case errors.Is(err, service.ErrAccessDenied),
+		errors.Is(err, service.ErrCardHold):
+		return http.StatusForbidden
+	case errors.Is(err, repository.ErrHoldNotFound),
+		errors.Is(err, repository.ErrAccountNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repository.ErrHoldNotOpen),
+		errors.Is(err, service.ErrInsufficientFunds):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"Bank/internal/middleware"
+	"Bank/internal/model"
+	"Bank/internal/repository"
+	"Bank/internal/service"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type PayeeHandler struct {
+	payeeSvc *service.PayeeService
+}
+
+func NewPayeeHandler(s *service.PayeeService) *PayeeHandler {
+	return &PayeeHandler{payeeSvc: s}
+}
+
+func (h *PayeeHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+
+	var req model.PayeeCreate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	payee, err := h.payeeSvc.Create(userID, &req)
+	if err != nil {
+		http.Error(w, err.Error(), payeeErrorCode(err))
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(payee)
+}
+
+func (h *PayeeHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+	list, err := h.payeeSvc.List(userID)
+	if err != nil {
+		http.Error(w, "cannot fetch payees", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(list)
+}
+
+func (h *PayeeHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+	payeeID, err := strconv.Atoi(mux.Vars(r)["payeeId"])
+	if err != nil {
+		http.Error(w, "invalid payee id", http.StatusBadRequest)
+		return
+	}
+
+	payee, err := h.payeeSvc.Get(userID, payeeID)
+	if err != nil {
+		http.Error(w, err.Error(), payeeErrorCode(err))
+		return
+	}
+	json.NewEncoder(w).Encode(payee)
+}
+
+func (h *PayeeHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+	payeeID, err := strconv.Atoi(mux.Vars(r)["payeeId"])
+	if err != nil {
+		http.Error(w, "invalid payee id", http.StatusBadRequest)
+		return
+	}
+
+	var req model.PayeeCreate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	payee, err := h.payeeSvc.Update(userID, payeeID, &req)
+	if err != nil {
+		http.Error(w, err.Error(), payeeErrorCode(err))
+		return
+	}
+	json.NewEncoder(w).Encode(payee)
+}
+
+func (h *PayeeHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+	payeeID, err := strconv.Atoi(mux.Vars(r)["payeeId"])
+	if err != nil {
+		http.Error(w, "invalid payee id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.payeeSvc.Delete(userID, payeeID); err != nil {
+		http.Error(w, err.Error(), payeeErrorCode(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func payeeErrorCode(err error) int {
+	switch {
+	case errors.Is(err, service.ErrAmountRequired),
+		errors.Is(err, service.ErrInvalidPhone):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrPayeeNotYours):
+		return http.StatusForbidden
+	case errors.Is(err, repository.ErrPayeeNotFound),
+		errors.Is(err, repository.ErrAccountNotFound),
+		errors.Is(err, service.ErrRecipientNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repository.ErrPayeeExists):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
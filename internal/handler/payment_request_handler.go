@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"Bank/internal/middleware"
+	"Bank/internal/model"
+	"Bank/internal/repository"
+	"Bank/internal/service"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type PaymentRequestHandler struct {
+	requestSvc *service.PaymentRequestService
+}
+
+func NewPaymentRequestHandler(s *service.PaymentRequestService) *PaymentRequestHandler {
+	return &PaymentRequestHandler{requestSvc: s}
+}
+
+func (h *PaymentRequestHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+
+	var req model.PaymentRequestCreate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	link, err := h.requestSvc.Create(userID, &req)
+	if err != nil {
+		http.Error(w, err.Error(), paymentRequestErrorCode(err))
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(link)
+}
+
+func (h *PaymentRequestHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+	list, err := h.requestSvc.List(userID)
+	if err != nil {
+		http.Error(w, "cannot fetch payment requests", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(list)
+}
+
+func (h *PaymentRequestHandler) Link(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+	requestID, err := strconv.Atoi(mux.Vars(r)["requestId"])
+	if err != nil {
+		http.Error(w, "invalid request id", http.StatusBadRequest)
+		return
+	}
+
+	link, err := h.requestSvc.Link(userID, requestID)
+	if err != nil {
+		http.Error(w, err.Error(), paymentRequestErrorCode(err))
+		return
+	}
+	json.NewEncoder(w).Encode(link)
+}
+
+func (h *PaymentRequestHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+	requestID, err := strconv.Atoi(mux.Vars(r)["requestId"])
+	if err != nil {
+		http.Error(w, "invalid request id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.requestSvc.Cancel(userID, requestID); err != nil {
+		http.Error(w, err.Error(), paymentRequestErrorCode(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *PaymentRequestHandler) View(w http.ResponseWriter, r *http.Request) {
+	view, err := h.requestSvc.View(mux.Vars(r)["token"])
+	if err != nil {
+		http.Error(w, err.Error(), paymentRequestErrorCode(err))
+		return
+	}
+	json.NewEncoder(w).Encode(view)
+}
+
+func (h *PaymentRequestHandler) Pay(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+
+	var req model.PaymentRequestPay
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	paid, err := h.requestSvc.Pay(userID, mux.Vars(r)["token"], req.FromAccountID, req.OTP)
+	if err != nil {
+		http.Error(w, err.Error(), paymentRequestErrorCode(err))
+		return
+	}
+	json.NewEncoder(w).Encode(paid)
+}
+
+func paymentRequestErrorCode(err error) int {
+	switch {
+	case errors.Is(err, service.ErrAccessDenied),
+		errors.Is(err, service.ErrPaymentRequestNotYours),
+		errors.Is(err, service.ErrTwoFactorRequired),
+		errors.Is(err, service.ErrOTPRequired),
+		errors.Is(err, service.ErrInvalidOTP):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrTooManyVerifications):
+		return http.StatusTooManyRequests
+	case errors.Is(err, service.ErrInvalidPaymentToken),
+		errors.Is(err, repository.ErrPaymentRequestNotFound),
+		errors.Is(err, repository.ErrAccountNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repository.ErrPaymentRequestNotOpen),
+		errors.Is(err, service.ErrInsufficientFunds):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrLimitExceeded),
+		errors.Is(err, service.ErrSameAccount):
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"Bank/internal/middleware"
+	"Bank/internal/model"
+	"Bank/internal/repository"
+	"Bank/internal/service"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type QRHandler struct {
+	qrSvc *service.QRService
+}
+
+func NewQRHandler(s *service.QRService) *QRHandler {
+	return &QRHandler{qrSvc: s}
+}
+
+func (h *QRHandler) Generate(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+	accountID, err := strconv.Atoi(mux.Vars(r)["accountId"])
+	if err != nil {
+		http.Error(w, "invalid account id", http.StatusBadRequest)
+		return
+	}
+
+	var amount float64
+	if v := r.URL.Query().Get("amount"); v != "" {
+		if amount, err = strconv.ParseFloat(v, 64); err != nil {
+			http.Error(w, "invalid amount", http.StatusBadRequest)
+			return
+		}
+	}
+
+	payload, err := h.qrSvc.Generate(userID, accountID, amount, r.URL.Query().Get("purpose"))
+	if err != nil {
+		code := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrAccessDenied):
+			code = http.StatusForbidden
+		case errors.Is(err, repository.ErrAccountNotFound):
+			code = http.StatusNotFound
+		case errors.Is(err, service.ErrInvalidAmount):
+			code = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+	json.NewEncoder(w).Encode(payload)
+}
+
+func (h *QRHandler) Parse(w http.ResponseWriter, r *http.Request) {
+	var req model.QRParse
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	draft, err := h.qrSvc.Parse(req.Payload)
+	if err != nil {
+		code := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrInvalidQRPayload):
+			code = http.StatusUnprocessableEntity
+		case errors.Is(err, repository.ErrAccountNotFound):
+			code = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+	json.NewEncoder(w).Encode(draft)
+}
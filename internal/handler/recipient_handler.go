@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"Bank/internal/middleware"
+	"Bank/internal/model"
+	"Bank/internal/repository"
+	"Bank/internal/service"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type RecipientHandler struct {
+	recipientSvc *service.RecipientService
+}
+
+func NewRecipientHandler(s *service.RecipientService) *RecipientHandler {
+	return &RecipientHandler{recipientSvc: s}
+}
+
+func (h *RecipientHandler) RegisterPhone(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+
+	var req model.PhoneAliasCreate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	alias, err := h.recipientSvc.RegisterPhone(userID, &req)
+	if err != nil {
+		http.Error(w, err.Error(), recipientErrorCode(err))
+		return
+	}
+	if alias.VerifiedAt == nil {
+		// номер заработает после подтверждения кодом из SMS
+		w.WriteHeader(http.StatusAccepted)
+	}
+	json.NewEncoder(w).Encode(alias)
+}
+
+func (h *RecipientHandler) ConfirmPhone(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+
+	var req model.PhoneAliasConfirm
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	alias, err := h.recipientSvc.ConfirmPhone(userID, &req)
+	if err != nil {
+		http.Error(w, err.Error(), recipientErrorCode(err))
+		return
+	}
+	json.NewEncoder(w).Encode(alias)
+}
+
+func (h *RecipientHandler) ListPhones(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+	list, err := h.recipientSvc.ListPhones(userID)
+	if err != nil {
+		http.Error(w, "cannot fetch aliases", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(list)
+}
+
+func (h *RecipientHandler) DeletePhone(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+	if err := h.recipientSvc.DeletePhone(userID, mux.Vars(r)["phone"]); err != nil {
+		http.Error(w, err.Error(), recipientErrorCode(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *RecipientHandler) Prepare(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+
+	var req model.RecipientTransferCreate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	confirmation, err := h.recipientSvc.Prepare(userID, &req)
+	if err != nil {
+		http.Error(w, err.Error(), recipientErrorCode(err))
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(confirmation)
+}
+
+func (h *RecipientHandler) Confirm(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+
+	var req model.RecipientTransferConfirm
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	res, err := h.recipientSvc.Confirm(userID, req.Token)
+	if err != nil {
+		http.Error(w, err.Error(), recipientErrorCode(err))
+		return
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+func recipientErrorCode(err error) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidPhone):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrAccessDenied),
+		errors.Is(err, service.ErrTwoFactorRequired),
+		errors.Is(err, service.ErrOTPRequired),
+		errors.Is(err, service.ErrInvalidOTP):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrTooManyVerifications):
+		return http.StatusTooManyRequests
+	case errors.Is(err, service.ErrRecipientNotFound),
+		errors.Is(err, repository.ErrAliasNotFound),
+		errors.Is(err, repository.ErrAccountNotFound),
+		errors.Is(err, repository.ErrConfirmationNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repository.ErrAliasTaken),
+		errors.Is(err, service.ErrInsufficientFunds):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrLimitExceeded),
+		errors.Is(err, service.ErrSameAccount),
+		errors.Is(err, repository.ErrAliasCodeInvalid):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, service.ErrTooManyAttempts):
+		return http.StatusTooManyRequests
+	case errors.Is(err, service.ErrSMSNotConfigured):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"Bank/internal/middleware"
+	"Bank/internal/model"
+	"Bank/internal/repository"
+	"Bank/internal/service"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// StaffHandler — запросы сотрудников банка о любом клиенте.
+type StaffHandler struct {
+	staffSvc *service.StaffService
+}
+
+func NewStaffHandler(s *service.StaffService) *StaffHandler {
+	return &StaffHandler{staffSvc: s}
+}
+
+func (h *StaffHandler) UserAccounts(w http.ResponseWriter, r *http.Request) {
+	staffID, userID, ok := staffTarget(w, r)
+	if !ok {
+		return
+	}
+	accounts, err := h.staffSvc.UserAccounts(staffID, userID, clientIP(r))
+	if err != nil {
+		http.Error(w, err.Error(), staffErrorCode(err))
+		return
+	}
+	json.NewEncoder(w).Encode(accounts)
+}
+
+func (h *StaffHandler) UserCredits(w http.ResponseWriter, r *http.Request) {
+	staffID, userID, ok := staffTarget(w, r)
+	if !ok {
+		return
+	}
+	credits, err := h.staffSvc.UserCredits(staffID, userID, clientIP(r))
+	if err != nil {
+		http.Error(w, err.Error(), staffErrorCode(err))
+		return
+	}
+	json.NewEncoder(w).Encode(credits)
+}
+
+func (h *StaffHandler) UserCards(w http.ResponseWriter, r *http.Request) {
+	staffID, userID, ok := staffTarget(w, r)
+	if !ok {
+		return
+	}
+	cards, err := h.staffSvc.UserCards(staffID, userID, clientIP(r))
+	if err != nil {
+		http.Error(w, err.Error(), staffErrorCode(err))
+		return
+	}
+	json.NewEncoder(w).Encode(cards)
+}
+
+// SetRole назначает пользователю роль (только администратор).
+func (h *StaffHandler) SetRole(w http.ResponseWriter, r *http.Request) {
+	adminID, userID, ok := staffTarget(w, r)
+	if !ok {
+		return
+	}
+
+	var req model.RoleUpdate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	u, err := h.staffSvc.SetRole(adminID, userID, req.Role, clientIP(r))
+	if err != nil {
+		http.Error(w, err.Error(), staffErrorCode(err))
+		return
+	}
+	json.NewEncoder(w).Encode(u)
+}
+
+// staffTarget возвращает сотрудника из токена и клиента из пути.
+func staffTarget(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	staffID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+	userID, err := strconv.Atoi(mux.Vars(r)["userId"])
+	if err != nil {
+		http.Error(w, "invalid user id", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return staffID, userID, true
+}
+
+func staffErrorCode(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrUserNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrOwnRole):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"Bank/internal/middleware"
+	"Bank/internal/model"
+	"Bank/internal/repository"
+	"Bank/internal/service"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type StandingOrderHandler struct {
+	orderSvc *service.StandingOrderService
+}
+
+func NewStandingOrderHandler(s *service.StandingOrderService) *StandingOrderHandler {
+	return &StandingOrderHandler{orderSvc: s}
+}
+
+func (h *StandingOrderHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+
+	var req model.StandingOrderCreate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	order, err := h.orderSvc.Create(userID, &req)
+	if err != nil {
+		code := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, service.ErrAccessDenied),
+			errors.Is(err, service.ErrTwoFactorRequired),
+			errors.Is(err, service.ErrOTPRequired),
+			errors.Is(err, service.ErrInvalidOTP):
+			code = http.StatusForbidden
+		case errors.Is(err, service.ErrTooManyVerifications):
+			code = http.StatusTooManyRequests
+		case errors.Is(err, repository.ErrAccountNotFound):
+			code = http.StatusNotFound
+		case errors.Is(err, service.ErrInvalidSchedule):
+			code = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(order)
+}
+
+func (h *StandingOrderHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+	list, err := h.orderSvc.List(userID)
+	if err != nil {
+		http.Error(w, "cannot fetch standing orders", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(list)
+}
+
+func (h *StandingOrderHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+	orderID, err := strconv.Atoi(mux.Vars(r)["orderId"])
+	if err != nil {
+		http.Error(w, "invalid order id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.orderSvc.Cancel(userID, orderID); err != nil {
+		http.Error(w, err.Error(), standingOrderErrorCode(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *StandingOrderHandler) Executions(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+	orderID, err := strconv.Atoi(mux.Vars(r)["orderId"])
+	if err != nil {
+		http.Error(w, "invalid order id", http.StatusBadRequest)
+		return
+	}
+
+	list, err := h.orderSvc.ListExecutions(userID, orderID)
+	if err != nil {
+		http.Error(w, err.Error(), standingOrderErrorCode(err))
+		return
+	}
+	json.NewEncoder(w).Encode(list)
+}
+
+func standingOrderErrorCode(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrStandingOrderNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrStandingOrderNotYours):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrStandingOrderInactive):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
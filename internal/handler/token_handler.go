@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"Bank/internal/middleware"
+	"Bank/internal/model"
+	"Bank/internal/repository"
+	"Bank/internal/service"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type TokenHandler struct {
+	tokenSvc *service.TokenService
+}
+
+func NewTokenHandler(s *service.TokenService) *TokenHandler {
+	return &TokenHandler{tokenSvc: s}
+}
+
+func (h *TokenHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+	cardID, err := strconv.Atoi(mux.Vars(r)["cardId"])
+	if err != nil {
+		http.Error(w, "invalid card id", http.StatusBadRequest)
+		return
+	}
+
+	var req model.TokenCreate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	token, err := h.tokenSvc.Issue(userID, cardID, &req)
+	if err != nil {
+		http.Error(w, err.Error(), tokenErrorCode(err))
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(token)
+}
+
+func (h *TokenHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+	cardID, err := strconv.Atoi(mux.Vars(r)["cardId"])
+	if err != nil {
+		http.Error(w, "invalid card id", http.StatusBadRequest)
+		return
+	}
+
+	list, err := h.tokenSvc.List(userID, cardID)
+	if err != nil {
+		http.Error(w, err.Error(), tokenErrorCode(err))
+		return
+	}
+	json.NewEncoder(w).Encode(list)
+}
+
+func (h *TokenHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+	vars := mux.Vars(r)
+	cardID, err := strconv.Atoi(vars["cardId"])
+	if err != nil {
+		http.Error(w, "invalid card id", http.StatusBadRequest)
+		return
+	}
+	tokenID, err := strconv.Atoi(vars["tokenId"])
+	if err != nil {
+		http.Error(w, "invalid token id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.tokenSvc.Delete(userID, cardID, tokenID); err != nil {
+		http.Error(w, err.Error(), tokenErrorCode(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Tokenize — выпуск токена по номеру карты внутренней системой (ключ API).
+func (h *TokenHandler) Tokenize(w http.ResponseWriter, r *http.Request) {
+	var req model.VaultTokenize
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	token, err := h.tokenSvc.Tokenize(&req)
+	if err != nil {
+		http.Error(w, err.Error(), tokenErrorCode(err))
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(token)
+}
+
+// Detokenize — выдача номера карты по токену внутренней системе (ключ API).
+func (h *TokenHandler) Detokenize(w http.ResponseWriter, r *http.Request) {
+	var req model.VaultDetokenize
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	card, err := h.tokenSvc.Detokenize(&req, clientIP(r))
+	if err != nil {
+		http.Error(w, err.Error(), tokenErrorCode(err))
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(card)
+}
+
+func tokenErrorCode(err error) int {
+	switch {
+	case errors.Is(err, service.ErrCardNotYours),
+		errors.Is(err, service.ErrTokenScope):
+		return http.StatusForbidden
+	case errors.Is(err, repository.ErrTokenNotFound),
+		errors.Is(err, repository.ErrCardNotFound),
+		errors.Is(err, repository.ErrAccountNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrTokenInactive),
+		errors.Is(err, repository.ErrCardStatus),
+		errors.Is(err, repository.ErrTokenTaken):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
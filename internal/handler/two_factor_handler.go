@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"Bank/internal/middleware"
+	"Bank/internal/model"
+	"Bank/internal/repository"
+	"Bank/internal/service"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+type TwoFactorHandler struct {
+	authSvc *service.AuthService
+	tfaSvc  *service.TwoFactorService
+}
+
+func NewTwoFactorHandler(a *service.AuthService, t *service.TwoFactorService) *TwoFactorHandler {
+	return &TwoFactorHandler{authSvc: a, tfaSvc: t}
+}
+
+func (h *TwoFactorHandler) Status(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+	st, err := h.tfaSvc.Status(userID)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(st)
+}
+
+// Setup выдаёт секрет и URI для QR-кода (требует пароль).
+func (h *TwoFactorHandler) Setup(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+
+	var req model.StepUp
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	setup, err := h.authSvc.SetupTwoFactor(userID, &req)
+	if err != nil {
+		http.Error(w, err.Error(), twoFactorErrorCode(err))
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(setup)
+}
+
+// Confirm включает второй фактор первым кодом и возвращает коды восстановления.
+func (h *TwoFactorHandler) Confirm(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+
+	var req model.TOTPConfirm
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	codes, err := h.tfaSvc.Confirm(userID, req.Code)
+	if err != nil {
+		http.Error(w, err.Error(), twoFactorErrorCode(err))
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(codes)
+}
+
+// Disable отключает второй фактор (требует пароль и код).
+func (h *TwoFactorHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+
+	var req model.StepUp
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.authSvc.DisableTwoFactor(userID, &req); err != nil {
+		http.Error(w, err.Error(), twoFactorErrorCode(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RegenerateRecoveryCodes выдаёт новый набор кодов, прежние перестают действовать.
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	userID, _ := strconv.Atoi(r.Context().Value(middleware.UserIDKey).(string))
+
+	var req model.StepUp
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	codes, err := h.authSvc.RegenerateRecoveryCodes(userID, &req)
+	if err != nil {
+		http.Error(w, err.Error(), twoFactorErrorCode(err))
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(codes)
+}
+
+func twoFactorErrorCode(err error) int {
+	switch {
+	case errors.Is(err, service.ErrTooManyVerifications):
+		return http.StatusTooManyRequests
+	case errors.Is(err, service.ErrStepUpFailed),
+		errors.Is(err, service.ErrInvalidOTP):
+		return http.StatusUnauthorized
+	case errors.Is(err, repository.ErrTOTPNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repository.ErrTOTPEnabled):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
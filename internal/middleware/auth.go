@@ -1,8 +1,11 @@
 package middleware
 
 import (
+	"Bank/internal/model"
 	"context"
+	"log"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -10,9 +13,20 @@ import (
 
 type ctxKey string
 
-const UserIDKey ctxKey = "userID"
+const (
+	UserIDKey    ctxKey = "userID"
+	SessionIDKey ctxKey = "sessionID"
+	RoleKey      ctxKey = "role"
+)
+
+// SessionValidator проверяет, что сессия токена не отозвана.
+type SessionValidator interface {
+	SessionActive(userID int, sessionID string) (bool, error)
+}
 
-func AuthMiddleware(jwtSecret string) func(http.Handler) http.Handler {
+// AuthMiddleware принимает access-токен и проверяет, что его сессия (jti) активна.
+// В контекст попадают пользователь, сессия и роль из токена.
+func AuthMiddleware(jwtSecret string, sessions SessionValidator) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			auth := r.Header.Get("Authorization")
@@ -28,16 +42,37 @@ func AuthMiddleware(jwtSecret string) func(http.Handler) http.Handler {
 			}
 
 			tokenStr := parts[1]
-			claims := &jwt.RegisteredClaims{}
+			claims := &model.AccessClaims{}
 			token, err := jwt.ParseWithClaims(tokenStr, claims, func(t *jwt.Token) (interface{}, error) {
 				return []byte(jwtSecret), nil
-			})
-			if err != nil || !token.Valid {
+			}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+			if err != nil || !token.Valid || claims.ID == "" {
+				http.Error(w, "invalid token", http.StatusUnauthorized)
+				return
+			}
+			userID, err := strconv.Atoi(claims.Subject)
+			if err != nil {
 				http.Error(w, "invalid token", http.StatusUnauthorized)
 				return
 			}
+			active, err := sessions.SessionActive(userID, claims.ID)
+			if err != nil {
+				log.Printf("Проверка сессии %s: %v", claims.ID, err)
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+			if !active {
+				http.Error(w, "session revoked", http.StatusUnauthorized)
+				return
+			}
 
 			ctx := context.WithValue(r.Context(), UserIDKey, claims.Subject)
+			ctx = context.WithValue(ctx, SessionIDKey, claims.ID)
+			role := claims.Role
+			if role == "" {
+				role = model.RoleCustomer
+			}
+			ctx = context.WithValue(ctx, RoleKey, role)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
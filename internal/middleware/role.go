@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"net/http"
+)
+
+// RequireRole пропускает только пользователей с одной из ролей roles.
+// Должен стоять после AuthMiddleware.
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	allowed := make(map[string]bool, len(roles))
+	for _, role := range roles {
+		allowed[role] = true
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role, _ := r.Context().Value(RoleKey).(string)
+			if !allowed[role] {
+				http.Error(w, "insufficient role", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
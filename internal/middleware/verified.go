@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// EmailVerifier сообщает, подтвердил ли пользователь email.
+type EmailVerifier interface {
+	EmailVerified(userID int) (bool, error)
+}
+
+// RequireVerifiedEmail пропускает только пользователей с подтверждённым email.
+// Должен стоять после AuthMiddleware.
+func RequireVerifiedEmail(users EmailVerifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sub, _ := r.Context().Value(UserIDKey).(string)
+			userID, err := strconv.Atoi(sub)
+			if err != nil {
+				http.Error(w, "invalid token", http.StatusUnauthorized)
+				return
+			}
+			verified, err := users.EmailVerified(userID)
+			if err != nil {
+				log.Printf("Проверка email пользователя #%d: %v", userID, err)
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return
+			}
+			if !verified {
+				http.Error(w, "email address is not verified", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
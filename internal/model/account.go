@@ -4,12 +4,15 @@ import (
 	"time"
 )
 
+// Account хранит учётный баланс (Balance); доступный баланс
+// (AvailableBalance) дополнительно уменьшен на активные холды.
 type Account struct {
-	ID        int       `json:"id"       db:"id"`
-	UserID    int       `json:"user_id"  db:"user_id"`
-	Balance   float64   `json:"balance"  db:"balance"`
-	Currency  string    `json:"currency" db:"currency"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	ID               int       `json:"id"                db:"id"`
+	UserID           int       `json:"user_id"           db:"user_id"`
+	Balance          float64   `json:"balance"           db:"balance"`
+	AvailableBalance float64   `json:"available_balance" db:"available_balance"`
+	Currency         string    `json:"currency"          db:"currency"`
+	CreatedAt        time.Time `json:"created_at"        db:"created_at"`
 }
 
 type AccountCreate struct {
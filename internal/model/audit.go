@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+)
+
+const (
+	AuditCardReveal = "card_reveal"
+	AuditCardPINSet = "card_pin_set"
+	// AuditDetokenize — выдача номера карты по токену внутренней системе.
+	AuditDetokenize = "card_detokenize"
+	// Просмотр сотрудником банка данных клиента и смена ролей.
+	AuditStaffViewAccounts = "staff_view_accounts"
+	AuditStaffViewCredits  = "staff_view_credits"
+	AuditStaffViewCards    = "staff_view_cards"
+	AuditRoleChange        = "role_change"
+)
+
+// AuditEntry — запись журнала чувствительных действий.
+type AuditEntry struct {
+	ID        int       `json:"id"                  db:"id"`
+	UserID    *int      `json:"user_id,omitempty"   db:"user_id"`
+	Action    string    `json:"action"              db:"action"`
+	TargetID  *int      `json:"target_id,omitempty" db:"target_id"`
+	Success   bool      `json:"success"             db:"success"`
+	IP        string    `json:"ip"                  db:"ip"`
+	Details   string    `json:"details"             db:"details"`
+	CreatedAt time.Time `json:"created_at"          db:"created_at"`
+}
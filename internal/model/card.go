@@ -4,22 +4,91 @@ import (
 	"time"
 )
 
+const (
+	CardActive      = "active"
+	CardBlockedUser = "blocked_user"
+	CardBlockedBank = "blocked_bank"
+	CardBlockedPIN  = "blocked_pin"
+	CardExpired     = "expired"
+	CardReissued    = "reissued"
+	// CardUsed — одноразовая карта после первой одобренной покупки.
+	CardUsed = "used"
+)
+
+const (
+	CardKindPhysical  = "physical"
+	CardKindVirtual   = "virtual"
+	CardKindSingleUse = "single_use"
+)
+
 type Card struct {
-	ID              int       `json:"id"                 db:"id"`
-	AccountID       int       `json:"account_id"         db:"account_id"`
-	NumberEncrypted []byte    `json:"-"                  db:"number_encrypted"`
-	ExpiryEncrypted []byte    `json:"-"                  db:"expiry_encrypted"`
-	CVVHash         string    `json:"-"                  db:"cvv_hash"`
-	HMAC            string    `json:"-"                  db:"hmac"`
-	CreatedAt       time.Time `json:"created_at"         db:"created_at"`
+	ID              int        `json:"id"                    db:"id"`
+	AccountID       int        `json:"account_id"            db:"account_id"`
+	NumberEncrypted []byte     `json:"-"                     db:"number_encrypted"`
+	ExpiryEncrypted []byte     `json:"-"                     db:"expiry_encrypted"`
+	KeyID           *int       `json:"-"                     db:"key_id"`
+	CVVHash         string     `json:"-"                     db:"cvv_hash"`
+	HMAC            string     `json:"-"                     db:"hmac"`
+	MaskedNumber    string     `json:"masked_number"         db:"masked_number"`
+	PaymentSystem   string     `json:"payment_system"        db:"payment_system"`
+	Product         string     `json:"product"               db:"product"`
+	PINHash         string     `json:"-"                     db:"pin_hash"`
+	PINAttempts     int        `json:"-"                     db:"pin_attempts"`
+	Kind            string     `json:"kind"                  db:"kind"`
+	SpendCap        *float64   `json:"spend_cap,omitempty"   db:"spend_cap"`
+	ValidUntil      *time.Time `json:"valid_until,omitempty" db:"valid_until"`
+	Status          string     `json:"status"                db:"status"`
+	ReplacedBy      *int       `json:"replaced_by,omitempty" db:"replaced_by"`
+	CreatedAt       time.Time  `json:"created_at"            db:"created_at"`
 }
 
+// CardResponse — карта в списке: номер только маскированный (первые 6 и последние 4 цифры).
 type CardResponse struct {
-	ID        int       `json:"id"`
-	Number    string    `json:"number"`
-	Expiry    string    `json:"expiry"`
-	CreatedAt time.Time `json:"created_at"`
+	ID            int        `json:"id"`
+	AccountID     int        `json:"account_id"`
+	MaskedNumber  string     `json:"masked_number"`
+	PaymentSystem string     `json:"payment_system"`
+	Product       string     `json:"product"`
+	Kind          string     `json:"kind"`
+	SpendCap      *float64   `json:"spend_cap,omitempty"`
+	ValidUntil    *time.Time `json:"valid_until,omitempty"`
+	Status        string     `json:"status"`
+	ReplacedBy    *int       `json:"replaced_by,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// VirtualCardCreate — выпуск виртуальной карты. SingleUse — карта блокируется
+// после первой одобренной покупки; SpendCap ограничивает сумму покупок за всё время.
+type VirtualCardCreate struct {
+	AccountID int      `json:"account_id" validate:"required"`
+	Product   string   `json:"product"    validate:"max=30"`
+	SingleUse bool     `json:"single_use"`
+	SpendCap  *float64 `json:"spend_cap"  validate:"omitempty,gt=0"`
+	ValidDays int      `json:"valid_days" validate:"omitempty,min=1,max=1095"`
+}
+
+func (v *VirtualCardCreate) Validate() error {
+	return validate.Struct(v)
+}
+
+// VirtualCardIssued — реквизиты виртуальной карты; CVV показывается только при выпуске.
+type VirtualCardIssued struct {
+	ID         int       `json:"id"`
+	Kind       string    `json:"kind"`
+	Number     string    `json:"number"`
+	Expiry     string    `json:"expiry"`
+	CVV        string    `json:"cvv"`
+	SpendCap   *float64  `json:"spend_cap,omitempty"`
+	ValidUntil time.Time `json:"valid_until"`
 }
+
+// CardReveal — полные реквизиты одной карты после повторной аутентификации.
+type CardReveal struct {
+	ID     int    `json:"id"`
+	Number string `json:"number"`
+	Expiry string `json:"expiry"`
+}
+
 type CardCreate struct {
 	AccountID int `json:"account_id" validate:"required"`
 }
@@ -27,3 +96,39 @@ type CardCreate struct {
 func (c *CardCreate) Validate() error {
 	return validate.Struct(c)
 }
+
+// CardPINSet — установка или сброс PIN после повторной аутентификации.
+type CardPINSet struct {
+	StepUp
+	PIN string `json:"pin" validate:"required,numeric,len=4"`
+}
+
+func (c *CardPINSet) Validate() error {
+	return validate.Struct(c)
+}
+
+// CardPINChange — смена PIN по текущему PIN.
+type CardPINChange struct {
+	CurrentPIN string `json:"current_pin" validate:"required,numeric,len=4"`
+	NewPIN     string `json:"new_pin"     validate:"required,numeric,len=4,nefield=CurrentPIN"`
+}
+
+func (c *CardPINChange) Validate() error {
+	return validate.Struct(c)
+}
+
+// CardControls — ограничения по карте. Пустые лимиты и списки не ограничивают;
+// Online — покупки без карты (интернет), Offline — по карте в терминале.
+type CardControls struct {
+	DailyLimit        *float64 `json:"daily_limit"         validate:"omitempty,gt=0"`
+	MonthlyLimit      *float64 `json:"monthly_limit"       validate:"omitempty,gt=0"`
+	PerTransactionMax *float64 `json:"per_transaction_max" validate:"omitempty,gt=0"`
+	AllowedMCC        []string `json:"allowed_mcc"         validate:"max=50,dive,numeric,len=4"`
+	AllowedCountries  []string `json:"allowed_countries"   validate:"max=50,dive,len=2,alpha,uppercase"`
+	OnlineEnabled     bool     `json:"online_enabled"`
+	OfflineEnabled    bool     `json:"offline_enabled"`
+}
+
+func (c *CardControls) Validate() error {
+	return validate.Struct(c)
+}
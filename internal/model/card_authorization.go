@@ -0,0 +1,71 @@
+package model
+
+// Типы сообщений упрощённого ISO 8583 (MTI).
+const (
+	MTIAuthRequest      = "0100"
+	MTIAuthResponse     = "0110"
+	MTIClearingAdvice   = "0220"
+	MTIClearingResponse = "0230"
+	MTIReversalRequest  = "0400"
+	MTIReversalResponse = "0410"
+)
+
+const (
+	ChannelOnline = "online"
+	ChannelPOS    = "pos"
+)
+
+// Коды ответа (DE39).
+const (
+	RespApproved          = "00"
+	RespDoNotHonor        = "05"
+	RespInvalidTxn        = "12"
+	RespInvalidCard       = "14"
+	RespOriginalNotFound  = "25"
+	RespInsufficientFunds = "51"
+	RespExpiredCard       = "54"
+	RespIncorrectPIN      = "55"
+	RespNotPermitted      = "57"
+	RespExceedsLimit      = "61"
+	RespRestrictedCard    = "62"
+	RespPINTriesExceeded  = "75"
+	RespSystemError       = "96"
+	RespInvalidCVV        = "N7"
+)
+
+// AcquirerMessage — сообщение эквайера. Имена полей соответствуют элементам
+// данных ISO 8583: PAN — DE2, Amount — DE4, STAN — DE11, Expiry (YYMM) — DE14,
+// MCC — DE18, RRN — DE37, ApprovalCode — DE38, MerchantID — DE42, MerchantName — DE43,
+// PIN — DE52 (в симуляторе передаётся открытым; пустой — покупка без PIN).
+// Channel заменяет DE22: online — покупка без карты, pos — в терминале.
+// MerchantCity и MerchantCountry (ISO 3166-1 alpha-2) — части DE43.
+// Для 0220 и 0400 исходная авторизация ищется по PAN и ApprovalCode.
+type AcquirerMessage struct {
+	MTI             string  `json:"mti"              validate:"required,oneof=0100 0220 0400"`
+	PAN             string  `json:"pan"              validate:"required,numeric,min=12,max=19"`
+	Expiry          string  `json:"expiry"           validate:"required_if=MTI 0100,omitempty,numeric,len=4"`
+	CVV             string  `json:"cvv"              validate:"required_if=MTI 0100,omitempty,numeric,len=3"`
+	Amount          float64 `json:"amount"           validate:"gte=0"`
+	STAN            string  `json:"stan"             validate:"required,numeric,len=6"`
+	RRN             string  `json:"rrn"              validate:"required,max=12"`
+	ApprovalCode    string  `json:"approval_code"    validate:"required_unless=MTI 0100,omitempty,len=6"`
+	MerchantID      string  `json:"merchant_id"      validate:"max=15"`
+	MerchantName    string  `json:"merchant_name"    validate:"max=40"`
+	MCC             string  `json:"mcc"              validate:"omitempty,numeric,len=4"`
+	PIN             string  `json:"pin"              validate:"omitempty,numeric,len=4"`
+	Channel         string  `json:"channel"          validate:"omitempty,oneof=online pos"`
+	MerchantCity    string  `json:"merchant_city"    validate:"max=13"`
+	MerchantCountry string  `json:"merchant_country" validate:"omitempty,len=2,alpha,uppercase"`
+}
+
+func (m *AcquirerMessage) Validate() error {
+	return validate.Struct(m)
+}
+
+type AcquirerResponse struct {
+	MTI          string `json:"mti"`
+	STAN         string `json:"stan"`
+	RRN          string `json:"rrn"`
+	ResponseCode string `json:"response_code"`
+	ApprovalCode string `json:"approval_code,omitempty"`
+}
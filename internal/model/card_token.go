@@ -0,0 +1,68 @@
+package model
+
+import (
+	"time"
+)
+
+const (
+	// TokenFormatPAN — формато-сохраняющий токен: 16–19 цифр, последние 4 как у карты.
+	TokenFormatPAN = "pan"
+	// TokenFormatRandom — непрозрачная случайная строка.
+	TokenFormatRandom = "random"
+)
+
+const (
+	TokenActive = "active"
+	// TokenSuspended — токен действует, но карта сейчас не активна; не хранится в БД.
+	TokenSuspended = "suspended"
+	TokenDeleted   = "deleted"
+)
+
+// CardToken — токен карты, ограниченный областью Scope (мерчант или назначение).
+type CardToken struct {
+	ID        int       `json:"id"         db:"id"`
+	CardID    int       `json:"card_id"    db:"card_id"`
+	Token     string    `json:"token"      db:"token"`
+	Format    string    `json:"format"     db:"format"`
+	Scope     string    `json:"scope"      db:"scope"`
+	Status    string    `json:"status"     db:"status"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// TokenCreate — выпуск токена для карты владельцем.
+type TokenCreate struct {
+	Format string `json:"format" validate:"omitempty,oneof=pan random"`
+	Scope  string `json:"scope"  validate:"required,max=100"`
+}
+
+func (t *TokenCreate) Validate() error {
+	return validate.Struct(t)
+}
+
+// VaultTokenize — выпуск токена по номеру карты внутренней системой банка.
+type VaultTokenize struct {
+	TokenCreate
+	PAN string `json:"pan" validate:"required,numeric,min=12,max=19"`
+}
+
+func (t *VaultTokenize) Validate() error {
+	return validate.Struct(t)
+}
+
+// VaultDetokenize — запрос номера карты по токену; Scope должен совпадать с областью токена.
+type VaultDetokenize struct {
+	Token string `json:"token" validate:"required,max=64"`
+	Scope string `json:"scope" validate:"required,max=100"`
+}
+
+func (t *VaultDetokenize) Validate() error {
+	return validate.Struct(t)
+}
+
+// Detokenized — реквизиты карты, на которую указывает токен.
+type Detokenized struct {
+	CardID int    `json:"card_id"`
+	PAN    string `json:"pan"`
+	Expiry string `json:"expiry"`
+}
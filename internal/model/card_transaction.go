@@ -0,0 +1,37 @@
+package model
+
+import (
+	"time"
+)
+
+// Статусы карточной операции: отклонена сразу или одобрена (authorized),
+// затем рассчитана клирингом (settled) или отменена реверсалом (reversed).
+const (
+	CardTxAuthorized = "authorized"
+	CardTxDeclined   = "declined"
+	CardTxSettled    = "settled"
+	CardTxReversed   = "reversed"
+)
+
+// CardTransaction — операция по карте от эквайера. TransactionID указывает
+// на проводку по счёту, созданную при расчёте.
+type CardTransaction struct {
+	ID              int       `json:"id"                       db:"id"`
+	CardID          *int      `json:"card_id,omitempty"        db:"card_id"`
+	HoldID          *int      `json:"hold_id,omitempty"        db:"hold_id"`
+	TransactionID   *int      `json:"transaction_id,omitempty" db:"transaction_id"`
+	STAN            string    `json:"stan"                     db:"stan"`
+	RRN             string    `json:"rrn"                      db:"rrn"`
+	ApprovalCode    string    `json:"approval_code,omitempty"  db:"approval_code"`
+	ResponseCode    string    `json:"response_code"            db:"response_code"`
+	Amount          float64   `json:"amount"                   db:"amount"`
+	CapturedAmount  float64   `json:"captured_amount"          db:"captured_amount"`
+	MerchantID      string    `json:"merchant_id"              db:"merchant_id"`
+	MerchantName    string    `json:"merchant_name"            db:"merchant_name"`
+	MerchantCity    string    `json:"merchant_city"            db:"merchant_city"`
+	MerchantCountry string    `json:"merchant_country"         db:"merchant_country"`
+	MCC             string    `json:"mcc"                      db:"mcc"`
+	Status          string    `json:"status"                   db:"status"`
+	CreatedAt       time.Time `json:"created_at"               db:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"               db:"updated_at"`
+}
@@ -0,0 +1,50 @@
+package model
+
+import (
+	"time"
+)
+
+const (
+	DisputeOpen            = "open"
+	DisputeUnderReview     = "under_review"
+	DisputeResolvedFavor   = "resolved_favor"
+	DisputeResolvedAgainst = "resolved_against"
+)
+
+// Dispute — спор клиента по операции.
+type Dispute struct {
+	ID            int       `json:"id"             db:"id"`
+	TransactionID int       `json:"transaction_id" db:"transaction_id"`
+	UserID        int       `json:"user_id"        db:"user_id"`
+	Reason        string    `json:"reason"         db:"reason"`
+	Status        string    `json:"status"         db:"status"`
+	Resolution    string    `json:"resolution"     db:"resolution"`
+	CreatedAt     time.Time `json:"created_at"     db:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"     db:"updated_at"`
+}
+
+type DisputeCreate struct {
+	Reason string `json:"reason" validate:"required,max=1000"`
+}
+
+func (d *DisputeCreate) Validate() error {
+	return validate.Struct(d)
+}
+
+// DisputeResolve — решение оператора: favor — в пользу клиента (операция отменяется).
+type DisputeResolve struct {
+	Outcome    string `json:"outcome"    validate:"required,oneof=favor against"`
+	Resolution string `json:"resolution" validate:"max=1000"`
+}
+
+func (d *DisputeResolve) Validate() error {
+	return validate.Struct(d)
+}
+
+type ReversalRequest struct {
+	Reason string `json:"reason" validate:"max=140"`
+}
+
+func (r *ReversalRequest) Validate() error {
+	return validate.Struct(r)
+}
@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+)
+
+const (
+	KeyActive  = "active"
+	KeyRetired = "retired"
+)
+
+// EncryptionKey — ключ шифрования данных (DEK), хранящийся зашифрованным мастер-ключом.
+type EncryptionKey struct {
+	ID         int        `json:"id"                   db:"id"`
+	WrappedKey []byte     `json:"-"                    db:"wrapped_key"`
+	Status     string     `json:"status"               db:"status"`
+	CreatedAt  time.Time  `json:"created_at"           db:"created_at"`
+	RetiredAt  *time.Time `json:"retired_at,omitempty" db:"retired_at"`
+}
+
+// KeyRotation — результат ротации: новый активный ключ и число карт,
+// которые ещё предстоит перешифровать.
+type KeyRotation struct {
+	Key          *EncryptionKey `json:"key"`
+	PendingCards int            `json:"pending_cards"`
+}
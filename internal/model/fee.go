@@ -0,0 +1,48 @@
+package model
+
+import (
+	"time"
+)
+
+const (
+	FeeOpWithdraw  = "withdraw"
+	FeeOpTransfer  = "transfer_p2p" // перевод другому клиенту
+	FeeOpFX        = "fx"
+	FeeOpCardIssue = "card_issue"
+)
+
+const (
+	FeeRuleFlat    = "flat"
+	FeeRulePercent = "percent"
+	FeeRuleTiered  = "tiered"
+)
+
+type FeeSchedule struct {
+	ID           int       `json:"id"             db:"id"`
+	Operation    string    `json:"operation"      db:"operation"`
+	RuleType     string    `json:"rule_type"      db:"rule_type"`
+	FlatAmount   float64   `json:"flat_amount"    db:"flat_amount"`
+	Percent      float64   `json:"percent"        db:"percent"`
+	MinFee       float64   `json:"min_fee"        db:"min_fee"`
+	MaxFee       float64   `json:"max_fee"        db:"max_fee"`
+	Tiers        []FeeTier `json:"tiers,omitempty" db:"tiers"`
+	FreePerMonth int       `json:"free_per_month" db:"free_per_month"`
+	Active       bool      `json:"active"         db:"active"`
+	CreatedAt    time.Time `json:"created_at"     db:"created_at"`
+}
+
+// FeeTier — ступень тарифа; UpTo == nil означает «без верхней границы».
+type FeeTier struct {
+	UpTo    *float64 `json:"up_to"`
+	Flat    float64  `json:"flat"`
+	Percent float64  `json:"percent"`
+}
+
+// FeeQuote — рассчитанная комиссия за операцию.
+type FeeQuote struct {
+	Operation     string  `json:"operation"`
+	Amount        float64 `json:"amount"`
+	Fee           float64 `json:"fee"`
+	Waived        bool    `json:"waived"`
+	FreeRemaining int     `json:"free_remaining"`
+}
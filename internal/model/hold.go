@@ -0,0 +1,44 @@
+package model
+
+import (
+	"time"
+)
+
+const (
+	HoldActive   = "active"
+	HoldCaptured = "captured"
+	HoldReleased = "released"
+	HoldExpired  = "expired"
+)
+
+// Источник холда. Карточный холд принадлежит авторизации, а не клиенту:
+// владелец счёта не может ни списать, ни снять его.
+const (
+	HoldSourceCustomer = "customer"
+	HoldSourceCard     = "card"
+)
+
+// Hold — блокировка суммы на счёте до её списания (capture) или снятия (release).
+type Hold struct {
+	ID             int       `json:"id"                       db:"id"`
+	AccountID      int       `json:"account_id"               db:"account_id"`
+	Amount         float64   `json:"amount"                   db:"amount"`
+	CapturedAmount float64   `json:"captured_amount"          db:"captured_amount"`
+	Status         string    `json:"status"                   db:"status"`
+	Source         string    `json:"source"                   db:"source"`
+	Description    string    `json:"description"              db:"description"`
+	TransactionID  *int      `json:"transaction_id,omitempty" db:"transaction_id"`
+	ExpiresAt      time.Time `json:"expires_at"               db:"expires_at"`
+	CreatedAt      time.Time `json:"created_at"               db:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"               db:"updated_at"`
+}
+
+type HoldCreate struct {
+	Amount         float64 `json:"amount"           validate:"required,gt=0"`
+	Description    string  `json:"description"      validate:"max=140"`
+	ExpiresInHours int     `json:"expires_in_hours" validate:"omitempty,min=1,max=720"`
+}
+
+func (h *HoldCreate) Validate() error {
+	return validate.Struct(h)
+}
@@ -0,0 +1,49 @@
+package model
+
+import (
+	"time"
+)
+
+const (
+	LimitOpWithdraw = "withdraw"
+	LimitOpTransfer = "transfer"
+	LimitOpPurchase = "purchase"
+)
+
+// TransactionLimit — настройка лимитов. Пустые поля наследуются:
+// лимит счёта — от лимита пользователя, лимит пользователя — от лимитов банка.
+type TransactionLimit struct {
+	ID              int       `json:"id"                          db:"id"`
+	UserID          int       `json:"-"                           db:"user_id"`
+	AccountID       *int      `json:"account_id,omitempty"        db:"account_id"`
+	Operation       string    `json:"operation"                   db:"operation"`
+	DailyLimit      *float64  `json:"daily_limit,omitempty"       db:"daily_limit"`
+	MonthlyLimit    *float64  `json:"monthly_limit,omitempty"     db:"monthly_limit"`
+	PerOperationMax *float64  `json:"per_operation_max,omitempty" db:"per_operation_max"`
+	UpdatedAt       time.Time `json:"updated_at"                  db:"updated_at"`
+}
+
+type TransactionLimitUpdate struct {
+	AccountID       *int     `json:"account_id"`
+	Operation       string   `json:"operation"         validate:"required,oneof=withdraw transfer purchase"`
+	DailyLimit      *float64 `json:"daily_limit"       validate:"omitempty,gt=0"`
+	MonthlyLimit    *float64 `json:"monthly_limit"     validate:"omitempty,gt=0"`
+	PerOperationMax *float64 `json:"per_operation_max" validate:"omitempty,gt=0"`
+}
+
+func (l *TransactionLimitUpdate) Validate() error {
+	return validate.Struct(l)
+}
+
+// LimitStatus — действующие лимиты и их использование.
+type LimitStatus struct {
+	AccountID        *int    `json:"account_id,omitempty"`
+	Operation        string  `json:"operation"`
+	PerOperationMax  float64 `json:"per_operation_max"`
+	DailyLimit       float64 `json:"daily_limit"`
+	DailyUsed        float64 `json:"daily_used"`
+	DailyRemaining   float64 `json:"daily_remaining"`
+	MonthlyLimit     float64 `json:"monthly_limit"`
+	MonthlyUsed      float64 `json:"monthly_used"`
+	MonthlyRemaining float64 `json:"monthly_remaining"`
+}
@@ -1,10 +1,24 @@
 package model
 
+// UserLogin — вход по email и паролю. Если у пользователя включён второй
+// фактор, OTP — код из приложения или код восстановления.
 type UserLogin struct {
 	Email    string `json:"email"    validate:"required,email"`
 	Password string `json:"password" validate:"required"`
+	OTP      string `json:"otp"      validate:"max=20"`
 }
 
 func (ul *UserLogin) Validate() error {
 	return validate.Struct(ul)
 }
+
+// StepUp — повторное подтверждение личности перед чувствительной операцией.
+// OTP обязателен, если у пользователя включён второй фактор.
+type StepUp struct {
+	Password string `json:"password" validate:"required"`
+	OTP      string `json:"otp"      validate:"max=20"`
+}
+
+func (s *StepUp) Validate() error {
+	return validate.Struct(s)
+}
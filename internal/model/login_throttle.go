@@ -0,0 +1,13 @@
+package model
+
+import (
+	"time"
+)
+
+// LoginThrottle — неудачные попытки входа по одному ключу (email или IP).
+type LoginThrottle struct {
+	Key           string     `db:"key"`
+	Failures      int        `db:"failures"`
+	LastFailureAt time.Time  `db:"last_failure_at"`
+	LockedUntil   *time.Time `db:"locked_until"`
+}
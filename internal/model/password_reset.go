@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+)
+
+// PasswordReset — одноразовый токен сброса пароля; сам токен уходит только в письмо.
+type PasswordReset struct {
+	ID        int        `db:"id"`
+	UserID    int        `db:"user_id"`
+	TokenHash string     `db:"token_hash"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
+
+type ForgotPassword struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+func (f *ForgotPassword) Validate() error {
+	return validate.Struct(f)
+}
+
+type ResetPassword struct {
+	Token    string `json:"token"    validate:"required,max=200"`
+	Password string `json:"password" validate:"required,min=6"`
+}
+
+func (r *ResetPassword) Validate() error {
+	return validate.Struct(r)
+}
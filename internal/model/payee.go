@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+)
+
+// Payee — сохранённый получатель. Задаётся либо номером счёта,
+// либо псевдонимом (username, email или телефон).
+type Payee struct {
+	ID            int       `json:"id"                       db:"id"`
+	UserID        int       `json:"-"                        db:"user_id"`
+	Nickname      string    `json:"nickname"                 db:"nickname"`
+	ToAccountID   *int      `json:"to_account_id,omitempty"  db:"to_account_id"`
+	RecipientType string    `json:"recipient_type,omitempty" db:"recipient_type"`
+	Recipient     string    `json:"recipient,omitempty"      db:"recipient"`
+	DefaultAmount *float64  `json:"default_amount,omitempty" db:"default_amount"`
+	Description   string    `json:"description"              db:"description"`
+	CreatedAt     time.Time `json:"created_at"               db:"created_at"`
+}
+
+type PayeeCreate struct {
+	Nickname      string   `json:"nickname"       validate:"required,max=50"`
+	ToAccountID   int      `json:"to_account_id"  validate:"required_without=Recipient,excluded_with=Recipient"`
+	RecipientType string   `json:"recipient_type" validate:"required_with=Recipient,omitempty,oneof=username email phone"`
+	Recipient     string   `json:"recipient"      validate:"max=100"`
+	DefaultAmount *float64 `json:"default_amount" validate:"omitempty,gt=0"`
+	Description   string   `json:"description"    validate:"max=140"`
+}
+
+func (p *PayeeCreate) Validate() error {
+	return validate.Struct(p)
+}
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"time"
+)
+
+const (
+	PaymentRequestPending    = "pending"
+	PaymentRequestProcessing = "processing"
+	PaymentRequestPaid       = "paid"
+	PaymentRequestCancelled  = "cancelled"
+	PaymentRequestExpired    = "expired" // вычисляется при чтении, в БД не хранится
+)
+
+type PaymentRequest struct {
+	ID                  int        `json:"id"                              db:"id"`
+	UserID              int        `json:"user_id"                         db:"user_id"`
+	ToAccountID         int        `json:"to_account_id"                   db:"to_account_id"`
+	Amount              float64    `json:"amount"                          db:"amount"`
+	Description         string     `json:"description"                     db:"description"`
+	Status              string     `json:"status"                          db:"status"`
+	ExpiresAt           time.Time  `json:"expires_at"                      db:"expires_at"`
+	PayerUserID         *int       `json:"payer_user_id,omitempty"         db:"payer_user_id"`
+	DebitTransactionID  *int       `json:"debit_transaction_id,omitempty"  db:"debit_transaction_id"`
+	CreditTransactionID *int       `json:"credit_transaction_id,omitempty" db:"credit_transaction_id"`
+	PaidAt              *time.Time `json:"paid_at,omitempty"               db:"paid_at"`
+	CreatedAt           time.Time  `json:"created_at"                      db:"created_at"`
+}
+
+type PaymentRequestCreate struct {
+	ToAccountID    int     `json:"to_account_id"    validate:"required"`
+	Amount         float64 `json:"amount"           validate:"required,gt=0"`
+	Description    string  `json:"description"      validate:"max=140"`
+	ExpiresInHours int     `json:"expires_in_hours" validate:"omitempty,min=1,max=720"`
+}
+
+func (p *PaymentRequestCreate) Validate() error {
+	return validate.Struct(p)
+}
+
+// PaymentLink — подписанная ссылка на оплату запроса; QRPayload
+// можно без изменений закодировать в QR-код.
+type PaymentLink struct {
+	Request   *PaymentRequest `json:"request"`
+	Token     string          `json:"token"`
+	URL       string          `json:"url"`
+	QRPayload string          `json:"qr_payload"`
+}
+
+// PaymentRequestView — то, что видит плательщик, открыв ссылку.
+type PaymentRequestView struct {
+	ID            int       `json:"id"`
+	RecipientName string    `json:"recipient_name"`
+	Amount        float64   `json:"amount"`
+	Description   string    `json:"description"`
+	Status        string    `json:"status"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
+
+type PaymentRequestPay struct {
+	FromAccountID int    `json:"from_account_id" validate:"required"`
+	OTP           string `json:"otp"             validate:"max=20"`
+}
+
+func (p *PaymentRequestPay) Validate() error {
+	return validate.Struct(p)
+}
@@ -0,0 +1,30 @@
+package model
+
+// QRPayload — строка платёжного QR-кода по ГОСТ Р 56042-2014.
+type QRPayload struct {
+	Payload string `json:"payload"`
+}
+
+type QRParse struct {
+	Payload string `json:"payload" validate:"required,max=2000"`
+}
+
+func (q *QRParse) Validate() error {
+	return validate.Struct(q)
+}
+
+// TransferDraft — черновик перевода, заполненный из QR-кода. ToAccountID
+// задан только для счетов нашего банка.
+type TransferDraft struct {
+	Name        string  `json:"name"`
+	PersonalAcc string  `json:"personal_acc"`
+	BankName    string  `json:"bank_name"`
+	BIC         string  `json:"bic"`
+	CorrespAcc  string  `json:"corresp_acc"`
+	Amount      float64 `json:"amount,omitempty"`
+	Purpose     string  `json:"purpose,omitempty"`
+	PayeeINN    string  `json:"payee_inn,omitempty"`
+	KPP         string  `json:"kpp,omitempty"`
+	Internal    bool    `json:"internal"`
+	ToAccountID *int    `json:"to_account_id,omitempty"`
+}
@@ -0,0 +1,74 @@
+package model
+
+import (
+	"time"
+)
+
+const (
+	RecipientUsername = "username"
+	RecipientEmail    = "email"
+	RecipientPhone    = "phone"
+)
+
+// PhoneAlias — телефон для входящих переводов. Пока VerifiedAt пуст,
+// номер ждёт подтверждения кодом из SMS и переводы по нему не проходят.
+type PhoneAlias struct {
+	Phone         string     `json:"phone"                 db:"phone"`
+	UserID        int        `json:"-"                     db:"user_id"`
+	AccountID     int        `json:"account_id"            db:"account_id"`
+	VerifiedAt    *time.Time `json:"verified_at,omitempty" db:"verified_at"`
+	CodeHash      string     `json:"-"                     db:"code_hash"`
+	CodeExpiresAt *time.Time `json:"-"                     db:"code_expires_at"`
+	CreatedAt     time.Time  `json:"created_at"            db:"created_at"`
+}
+
+type PhoneAliasCreate struct {
+	Phone     string `json:"phone"      validate:"required,min=10,max=20"`
+	AccountID int    `json:"account_id" validate:"required"`
+}
+
+func (p *PhoneAliasCreate) Validate() error {
+	return validate.Struct(p)
+}
+
+// PhoneAliasConfirm — код из SMS, подтверждающий владение номером.
+type PhoneAliasConfirm struct {
+	Phone string `json:"phone" validate:"required,min=10,max=20"`
+	Code  string `json:"code"  validate:"required,numeric,len=6"`
+}
+
+func (p *PhoneAliasConfirm) Validate() error {
+	return validate.Struct(p)
+}
+
+type TransferConfirmation struct {
+	Token         string     `json:"token"          db:"token"`
+	UserID        int        `json:"-"              db:"user_id"`
+	FromAccountID int        `json:"from_account_id" db:"from_account_id"`
+	ToAccountID   int        `json:"-"              db:"to_account_id"`
+	Amount        float64    `json:"amount"         db:"amount"`
+	RecipientName string     `json:"recipient_name" db:"recipient_name"`
+	ExpiresAt     time.Time  `json:"expires_at"     db:"expires_at"`
+	ConfirmedAt   *time.Time `json:"-"              db:"confirmed_at"`
+	CreatedAt     time.Time  `json:"-"              db:"created_at"`
+}
+
+type RecipientTransferCreate struct {
+	FromAccountID int     `json:"from_account_id" validate:"required"`
+	RecipientType string  `json:"recipient_type"  validate:"required,oneof=username email phone"`
+	Recipient     string  `json:"recipient"       validate:"required,max=100"`
+	Amount        float64 `json:"amount"          validate:"required,gt=0"`
+	OTP           string  `json:"otp"             validate:"max=20"`
+}
+
+func (r *RecipientTransferCreate) Validate() error {
+	return validate.Struct(r)
+}
+
+type RecipientTransferConfirm struct {
+	Token string `json:"token" validate:"required"`
+}
+
+func (r *RecipientTransferConfirm) Validate() error {
+	return validate.Struct(r)
+}
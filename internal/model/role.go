@@ -0,0 +1,29 @@
+package model
+
+import (
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Роли пользователей. Клиент видит только свои данные, сотрудники банка —
+// данные любых клиентов в пределах своей роли.
+const (
+	RoleCustomer    = "customer"
+	RoleOperator    = "operator"
+	RoleRiskOfficer = "risk_officer"
+	RoleAdmin       = "admin"
+)
+
+type RoleUpdate struct {
+	Role string `json:"role" validate:"required,oneof=customer operator risk_officer admin"`
+}
+
+func (r *RoleUpdate) Validate() error {
+	return validate.Struct(r)
+}
+
+// AccessClaims — claims access-токена. Смена роли отзывает сессии
+// пользователя, поэтому роль в токене не устаревает.
+type AccessClaims struct {
+	Role string `json:"role,omitempty"`
+	jwt.RegisteredClaims
+}
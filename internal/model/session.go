@@ -0,0 +1,36 @@
+package model
+
+import (
+	"time"
+)
+
+// Session — сессия входа пользователя.
+type Session struct {
+	ID           string     `json:"id"                   db:"id"`
+	UserID       int        `json:"-"                    db:"user_id"`
+	RefreshHash  string     `json:"-"                    db:"refresh_hash"`
+	PreviousHash string     `json:"-"                    db:"previous_hash"`
+	IP           string     `json:"ip"                   db:"ip"`
+	UserAgent    string     `json:"user_agent"           db:"user_agent"`
+	ExpiresAt    time.Time  `json:"expires_at"           db:"expires_at"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	CreatedAt    time.Time  `json:"created_at"           db:"created_at"`
+	LastUsedAt   time.Time  `json:"last_used_at"         db:"last_used_at"`
+}
+
+// TokenPair — выдаётся при входе и обновлении: короткоживущий access-токен
+// и одноразовый refresh-токен для получения следующей пары.
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+type TokenRefresh struct {
+	RefreshToken string `json:"refresh_token" validate:"required,max=200"`
+}
+
+func (t *TokenRefresh) Validate() error {
+	return validate.Struct(t)
+}
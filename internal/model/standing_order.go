@@ -0,0 +1,69 @@
+package model
+
+import (
+	"time"
+)
+
+const (
+	FrequencyOnce    = "once"
+	FrequencyWeekly  = "weekly"
+	FrequencyMonthly = "monthly"
+)
+
+const (
+	StandingOrderActive    = "active"
+	StandingOrderCompleted = "completed"
+	StandingOrderCancelled = "cancelled"
+	StandingOrderFailed    = "failed"
+)
+
+const (
+	ExecutionSuccess = "success"
+	ExecutionRetry   = "retry"
+	ExecutionFailed  = "failed"
+)
+
+type StandingOrder struct {
+	ID              int        `json:"id"               db:"id"`
+	UserID          int        `json:"user_id"          db:"user_id"`
+	FromAccountID   int        `json:"from_account_id"  db:"from_account_id"`
+	ToAccountID     int        `json:"to_account_id"    db:"to_account_id"`
+	Amount          float64    `json:"amount"           db:"amount"`
+	Frequency       string     `json:"frequency"        db:"frequency"`
+	DayOfMonth      *int       `json:"day_of_month"     db:"day_of_month"`
+	EndDate         *time.Time `json:"end_date"         db:"end_date"`
+	MaxExecutions   *int       `json:"max_executions"   db:"max_executions"`
+	ExecutionsCount int        `json:"executions_count" db:"executions_count"`
+	ScheduledFor    time.Time  `json:"scheduled_for"    db:"scheduled_for"`
+	NextRunAt       time.Time  `json:"next_run_at"      db:"next_run_at"`
+	Attempts        int        `json:"attempts"         db:"attempts"`
+	Status          string     `json:"status"           db:"status"`
+	CreatedAt       time.Time  `json:"created_at"       db:"created_at"`
+}
+
+type StandingOrderCreate struct {
+	FromAccountID int        `json:"from_account_id" validate:"required"`
+	ToAccountID   int        `json:"to_account_id"   validate:"required,nefield=FromAccountID"`
+	Amount        float64    `json:"amount"          validate:"required,gt=0"`
+	Frequency     string     `json:"frequency"       validate:"required,oneof=once weekly monthly"`
+	StartDate     time.Time  `json:"start_date"      validate:"required"`
+	DayOfMonth    *int       `json:"day_of_month"    validate:"omitempty,min=1,max=31"`
+	EndDate       *time.Time `json:"end_date"`
+	MaxExecutions *int       `json:"max_executions"  validate:"omitempty,gt=0"`
+	OTP           string     `json:"otp"             validate:"max=20"`
+}
+
+func (s *StandingOrderCreate) Validate() error {
+	return validate.Struct(s)
+}
+
+type StandingOrderExecution struct {
+	ID            int        `json:"id"                      db:"id"`
+	OrderID       int        `json:"order_id"                db:"order_id"`
+	Attempt       int        `json:"attempt"                 db:"attempt"`
+	ScheduledFor  *time.Time `json:"scheduled_for,omitempty" db:"scheduled_for"`
+	Status        string     `json:"status"                  db:"status"`
+	TransactionID *int       `json:"transaction_id"          db:"transaction_id"`
+	Error         string     `json:"error,omitempty"         db:"error"`
+	ExecutedAt    time.Time  `json:"executed_at"             db:"executed_at"`
+}
@@ -4,13 +4,36 @@ import (
 	"time"
 )
 
+// Типы компенсирующих проводок: возврат средств на счёт и списание со счёта.
+const (
+	TxReversalIn  = "reversal_in"
+	TxReversalOut = "reversal_out"
+)
+
+// Направление проводки относительно счёта.
+const (
+	TxDebit  = "debit"
+	TxCredit = "credit"
+)
+
+// Типы списаний по холдам: произвольный холд и покупка по карте.
+const (
+	TxHoldCapture  = "hold_capture"
+	TxCardPurchase = "card_purchase"
+)
+
+// Transaction — проводка по счёту. CounterpartID связывает ноги перевода,
+// ReversalOf указывает на операцию, которую отменяет проводка.
 type Transaction struct {
-	ID          int       `json:"id"          db:"id"`
-	AccountID   int       `json:"account_id"  db:"account_id"`
-	Amount      float64   `json:"amount"      db:"amount"`
-	Type        string    `json:"type"        db:"type"`
-	Description string    `json:"description" db:"description"`
-	CreatedAt   time.Time `json:"created_at"  db:"created_at"`
+	ID            int       `json:"id"                       db:"id"`
+	AccountID     int       `json:"account_id"               db:"account_id"`
+	Amount        float64   `json:"amount"                   db:"amount"`
+	Type          string    `json:"type"                     db:"type"`
+	Direction     string    `json:"direction"                db:"direction"`
+	Description   string    `json:"description"              db:"description"`
+	CounterpartID *int      `json:"counterpart_id,omitempty" db:"counterpart_id"`
+	ReversalOf    *int      `json:"reversal_of,omitempty"    db:"reversal_of"`
+	CreatedAt     time.Time `json:"created_at"               db:"created_at"`
 }
 
 type TransactionCreate struct {
@@ -23,3 +46,22 @@ type TransactionCreate struct {
 func (t *TransactionCreate) Validate() error {
 	return validate.Struct(t)
 }
+
+// TransferResult — проводки перевода; Fee заполнен, если взималась комиссия.
+type TransferResult struct {
+	Debit  *Transaction `json:"debit"`
+	Credit *Transaction `json:"credit"`
+	Fee    *Transaction `json:"fee,omitempty"`
+}
+
+// CardTransferCreate — перевод на карту по её номеру.
+type CardTransferCreate struct {
+	FromAccountID int     `json:"from_account_id" validate:"required"`
+	ToCard        string  `json:"to_card"         validate:"required,numeric,min=12,max=19"`
+	Amount        float64 `json:"amount"          validate:"required,gt=0"`
+	OTP           string  `json:"otp"             validate:"max=20"`
+}
+
+func (c *CardTransferCreate) Validate() error {
+	return validate.Struct(c)
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"time"
+)
+
+// UserTOTP — второй фактор пользователя. До подтверждения первым кодом
+// Enabled = false и вход выполняется без него.
+type UserTOTP struct {
+	UserID          int        `db:"user_id"`
+	SecretEncrypted []byte     `db:"secret_encrypted"`
+	KeyID           int        `db:"key_id"`
+	Enabled         bool       `db:"enabled"`
+	LastCounter     int64      `db:"last_counter"`
+	CreatedAt       time.Time  `db:"created_at"`
+	EnabledAt       *time.Time `db:"enabled_at"`
+}
+
+// TOTPSetup — секрет для приложения-аутентификатора; URI кодируется в QR.
+type TOTPSetup struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// TOTPConfirm — первый код из приложения, включающий второй фактор.
+type TOTPConfirm struct {
+	Code string `json:"code" validate:"required,numeric,len=6"`
+}
+
+func (t *TOTPConfirm) Validate() error {
+	return validate.Struct(t)
+}
+
+// RecoveryCodes — коды восстановления; показываются один раз.
+type RecoveryCodes struct {
+	Codes []string `json:"recovery_codes"`
+}
+
+type TwoFactorStatus struct {
+	Enabled           bool       `json:"enabled"`
+	EnabledAt         *time.Time `json:"enabled_at,omitempty"`
+	RecoveryCodesLeft int        `json:"recovery_codes_left"`
+}
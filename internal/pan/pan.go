@@ -0,0 +1,177 @@
+// Package pan — номера платёжных карт: проверка Luhn, диапазоны BIN,
+// определение платёжной системы и маскирование.
+package pan
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+const (
+	BrandMir        = "MIR"
+	BrandVisa       = "VISA"
+	BrandMastercard = "MASTERCARD"
+	BrandUnknown    = "UNKNOWN"
+)
+
+var ErrInvalidRange = errors.New("invalid BIN range")
+
+// Range — диапазон префиксов номеров (BIN) карточного продукта.
+// From и To одной длины, включительно: "2200"–"2204" покрывает 2200…, 2201…, …, 2204….
+type Range struct {
+	Product string
+	Brand   string
+	From    string
+	To      string
+	Length  int
+}
+
+// Contains сообщает, попадает ли номер в диапазон.
+func (r Range) Contains(number string) bool {
+	if len(number) != r.Length || len(number) < len(r.From) {
+		return false
+	}
+	prefix := number[:len(r.From)]
+	return prefix >= r.From && prefix <= r.To
+}
+
+// ParseRanges разбирает список вида
+// "mir_classic=MIR:2200-2204,visa_classic=VISA:400000-400099:16".
+// Длина номера необязательна, по умолчанию 16.
+func ParseRanges(s string) ([]Range, error) {
+	var out []Range
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		product, spec, ok := strings.Cut(item, "=")
+		if !ok || product == "" {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidRange, item)
+		}
+		parts := strings.Split(spec, ":")
+		if len(parts) < 2 || len(parts) > 3 {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidRange, item)
+		}
+		from, to, ok := strings.Cut(parts[1], "-")
+		if !ok {
+			to = from
+		}
+		r := Range{Product: product, Brand: strings.ToUpper(parts[0]), From: from, To: to, Length: 16}
+		if len(parts) == 3 {
+			n, err := strconv.Atoi(parts[2])
+			if err != nil {
+				return nil, fmt.Errorf("%w: %q", ErrInvalidRange, item)
+			}
+			r.Length = n
+		}
+		if !isDigits(from) || !isDigits(to) || len(from) != len(to) || from > to ||
+			r.Length < 12 || r.Length > 19 || len(from) >= r.Length {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidRange, item)
+		}
+		out = append(out, r)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("%w: empty list", ErrInvalidRange)
+	}
+	return out, nil
+}
+
+// Generate выдаёт случайный номер из диапазона с корректной контрольной цифрой.
+func Generate(r Range) string {
+	from, _ := strconv.ParseInt(r.From, 10, 64)
+	to, _ := strconv.ParseInt(r.To, 10, 64)
+	prefix := fmt.Sprintf("%0*d", len(r.From), from+randInt(to-from+1))
+
+	var b strings.Builder
+	b.WriteString(prefix)
+	for b.Len() < r.Length-1 {
+		b.WriteByte(byte('0' + randInt(10)))
+	}
+	body := b.String()
+	return body + strconv.Itoa(CheckDigit(body))
+}
+
+// Valid проверяет длину, цифры и контрольную сумму Luhn.
+func Valid(number string) bool {
+	if len(number) < 12 || len(number) > 19 || !isDigits(number) {
+		return false
+	}
+	return CheckDigit(number[:len(number)-1]) == int(number[len(number)-1]-'0')
+}
+
+// CheckDigit вычисляет контрольную цифру Luhn для номера без неё.
+func CheckDigit(body string) int {
+	sum := 0
+	for i := 0; i < len(body); i++ {
+		d := int(body[len(body)-1-i] - '0')
+		if i%2 == 0 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return (10 - sum%10) % 10
+}
+
+// Token выдаёт формато-сохраняющий токен номера: той же длины, с теми же
+// последними 4 цифрами, но с неверной контрольной суммой Luhn, чтобы токен
+// нельзя было спутать с настоящим номером карты.
+func Token(number string) string {
+	last4 := number[len(number)-4:]
+	for {
+		var b strings.Builder
+		for b.Len() < len(number)-4 {
+			b.WriteByte(byte('0' + randInt(10)))
+		}
+		b.WriteString(last4)
+		if t := b.String(); !Valid(t) {
+			return t
+		}
+	}
+}
+
+// Brand определяет платёжную систему по первым цифрам номера.
+func Brand(number string) string {
+	switch {
+	case len(number) >= 4 && number[:4] >= "2200" && number[:4] <= "2204":
+		return BrandMir
+	case strings.HasPrefix(number, "4"):
+		return BrandVisa
+	case len(number) >= 2 && number[:2] >= "51" && number[:2] <= "55",
+		len(number) >= 4 && number[:4] >= "2221" && number[:4] <= "2720":
+		return BrandMastercard
+	}
+	return BrandUnknown
+}
+
+// Mask оставляет открытыми первые 6 и последние 4 цифры номера.
+func Mask(number string) string {
+	if len(number) <= 10 {
+		return number
+	}
+	return number[:6] + strings.Repeat("*", len(number)-10) + number[len(number)-4:]
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func randInt(n int64) int64 {
+	v, _ := rand.Int(rand.Reader, big.NewInt(n))
+	return v.Int64()
+}
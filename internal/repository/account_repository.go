@@ -13,6 +13,10 @@ type AccountRepository interface {
 	GetByID(id int) (*model.Account, error)
 	ListByUser(userID int) ([]*model.Account, error)
 	UpdateBalance(tx *sql.Tx, accountID int, newBalance float64) error
+	// GetForUpdateTx читает счёт, блокируя строку до конца транзакции.
+	GetForUpdateTx(tx *sql.Tx, id int) (*model.Account, error)
+	// AddBalanceTx меняет баланс на delta относительно текущего значения в БД.
+	AddBalanceTx(tx *sql.Tx, accountID int, delta float64) error
 }
 
 type accountRepo struct {
@@ -29,15 +33,23 @@ func (r *accountRepo) Create(a *model.Account) error {
         VALUES($1, $2, $3)
         RETURNING id, created_at
     `
+	a.AvailableBalance = a.Balance
 	return r.db.QueryRow(query, a.UserID, a.Balance, a.Currency).
 		Scan(&a.ID, &a.CreatedAt)
 }
 
+// accountColumns дополнительно вычисляет доступный баланс за вычетом активных холдов.
+const accountColumns = `
+        a.id, a.user_id, a.balance,
+        a.balance - COALESCE((SELECT SUM(h.amount) FROM holds h WHERE h.account_id = a.id AND h.status = 'active'), 0),
+        a.currency, a.created_at
+`
+
 func (r *accountRepo) GetByID(id int) (*model.Account, error) {
 	a := &model.Account{}
-	query := `SELECT id, user_id, balance, currency, created_at FROM accounts WHERE id = $1`
+	query := `SELECT ` + accountColumns + ` FROM accounts a WHERE a.id = $1`
 	err := r.db.QueryRow(query, id).
-		Scan(&a.ID, &a.UserID, &a.Balance, &a.Currency, &a.CreatedAt)
+		Scan(&a.ID, &a.UserID, &a.Balance, &a.AvailableBalance, &a.Currency, &a.CreatedAt)
 	if err == sql.ErrNoRows {
 		return nil, ErrAccountNotFound
 	}
@@ -45,7 +57,7 @@ func (r *accountRepo) GetByID(id int) (*model.Account, error) {
 }
 
 func (r *accountRepo) ListByUser(userID int) ([]*model.Account, error) {
-	query := `SELECT id, user_id, balance, currency, created_at FROM accounts WHERE user_id = $1`
+	query := `SELECT ` + accountColumns + ` FROM accounts a WHERE a.user_id = $1`
 	rows, err := r.db.Query(query, userID)
 	if err != nil {
 		return nil, err
@@ -55,7 +67,7 @@ func (r *accountRepo) ListByUser(userID int) ([]*model.Account, error) {
 	var list []*model.Account
 	for rows.Next() {
 		a := &model.Account{}
-		if err := rows.Scan(&a.ID, &a.UserID, &a.Balance, &a.Currency, &a.CreatedAt); err != nil {
+		if err := rows.Scan(&a.ID, &a.UserID, &a.Balance, &a.AvailableBalance, &a.Currency, &a.CreatedAt); err != nil {
 			return nil, err
 		}
 		list = append(list, a)
@@ -74,3 +86,26 @@ func (r *accountRepo) UpdateBalance(tx *sql.Tx, accountID int, newBalance float6
 	}
 	return nil
 }
+
+func (r *accountRepo) GetForUpdateTx(tx *sql.Tx, id int) (*model.Account, error) {
+	a := &model.Account{}
+	query := `SELECT ` + accountColumns + ` FROM accounts a WHERE a.id = $1 FOR UPDATE`
+	err := tx.QueryRow(query, id).
+		Scan(&a.ID, &a.UserID, &a.Balance, &a.AvailableBalance, &a.Currency, &a.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrAccountNotFound
+	}
+	return a, err
+}
+
+func (r *accountRepo) AddBalanceTx(tx *sql.Tx, accountID int, delta float64) error {
+	query := `UPDATE accounts SET balance = balance + $1 WHERE id = $2`
+	res, err := tx.Exec(query, delta, accountID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return ErrAccountNotFound
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"Bank/internal/model"
+	"database/sql"
+	"time"
+)
+
+type AuditRepository interface {
+	Create(e *model.AuditEntry) error
+	// CountSince — число действий пользователя с момента since.
+	CountSince(userID int, action string, since time.Time) (int, error)
+}
+
+type auditRepo struct {
+	db *sql.DB
+}
+
+func NewAuditRepository(db *sql.DB) AuditRepository {
+	return &auditRepo{db: db}
+}
+
+func (r *auditRepo) Create(e *model.AuditEntry) error {
+	query := `
+        INSERT INTO audit_log(user_id, action, target_id, success, ip, details)
+        VALUES($1, $2, $3, $4, $5, $6)
+        RETURNING id, created_at
+    `
+	return r.db.QueryRow(query, e.UserID, e.Action, e.TargetID, e.Success, e.IP, e.Details).
+		Scan(&e.ID, &e.CreatedAt)
+}
+
+func (r *auditRepo) CountSince(userID int, action string, since time.Time) (int, error) {
+	query := `SELECT COUNT(*) FROM audit_log WHERE user_id = $1 AND action = $2 AND created_at >= $3`
+	var n int
+	err := r.db.QueryRow(query, userID, action, since).Scan(&n)
+	return n, err
+}
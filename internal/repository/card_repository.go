@@ -4,14 +4,53 @@ import (
 	"Bank/internal/model"
 	"database/sql"
 	"errors"
+
+	"github.com/lib/pq"
 )
 
-var ErrCardNotFound = errors.New("card not found")
+var (
+	ErrCardNotFound    = errors.New("card not found")
+	ErrCardNumberTaken = errors.New("card number already issued")
+	// ErrCardStatus возвращается, если текущий статус карты не допускает операцию.
+	ErrCardStatus = errors.New("card status does not allow this operation")
+	// ErrCardKeyChanged возвращается, если карту успели перешифровать другим запуском.
+	ErrCardKeyChanged = errors.New("card was re-encrypted concurrently")
+)
 
 type CardRepository interface {
-	Create(c *model.Card) error
+	CreateTx(tx *sql.Tx, c *model.Card) error
 	ListByAccount(accountID int) ([]*model.Card, error)
+	ListByStatus(status string) ([]*model.Card, error)
 	GetByID(id int) (*model.Card, error)
+	GetByHMAC(mac string) (*model.Card, error)
+	// UpdateStatus меняет статус карты, только если текущий входит в from.
+	UpdateStatus(c *model.Card, from []string, status string) error
+	// LockActiveTx блокирует строку активной карты до конца транзакции;
+	// ErrCardStatus — карта уже не активна.
+	LockActiveTx(tx *sql.Tx, c *model.Card) error
+	// ClaimSingleUseTx переводит активную одноразовую карту в 'used' в транзакции;
+	// ErrCardStatus — карту уже использовала другая авторизация.
+	ClaimSingleUseTx(tx *sql.Tx, c *model.Card) error
+	// SetMasked сохраняет маскированный номер и платёжную систему карты.
+	SetMasked(c *model.Card) error
+	GetControls(cardID int) (*model.CardControls, error)
+	UpdateControls(cardID int, ctl *model.CardControls) error
+	// SetPIN сохраняет хеш PIN, обнуляет счётчик попыток и снимает блокировку по PIN.
+	SetPIN(c *model.Card, pinHash string) error
+	// RegisterPINFailure увеличивает счётчик неверных PIN и блокирует карту,
+	// когда он достигает maxAttempts.
+	RegisterPINFailure(c *model.Card, maxAttempts int) error
+	ResetPINAttempts(c *model.Card) error
+	// ReissueTx помечает карту перевыпущенной, связывает её с новой
+	// и переносит на новую карту действующие токены.
+	ReissueTx(tx *sql.Tx, c *model.Card, from []string, replacedBy int) error
+	// ListByStaleKey возвращает до limit карт с id больше afterID,
+	// зашифрованных не ключом keyID.
+	ListByStaleKey(keyID, afterID, limit int) ([]*model.Card, error)
+	CountByStaleKey(keyID int) (int, error)
+	// SetEncryption сохраняет реквизиты, перешифрованные ключом keyID,
+	// если с момента чтения карту не перешифровали.
+	SetEncryption(c *model.Card, keyID int, numberEnc, expiryEnc []byte) error
 }
 
 type cardRepo struct {
@@ -22,49 +61,264 @@ func NewCardRepository(db *sql.DB) CardRepository {
 	return &cardRepo{db: db}
 }
 
-func (r *cardRepo) Create(c *model.Card) error {
+const cardColumns = `id, account_id, number_encrypted, expiry_encrypted, key_id, cvv_hash, hmac, masked_number, payment_system, product,
+        pin_hash, pin_attempts, kind, spend_cap, valid_until, status, replaced_by, created_at`
+
+func scanCard(row interface{ Scan(...interface{}) error }) (*model.Card, error) {
+	c := &model.Card{}
+	var replacedBy, keyID sql.NullInt64
+	var spendCap sql.NullFloat64
+	var validUntil sql.NullTime
+	err := row.Scan(&c.ID, &c.AccountID, &c.NumberEncrypted, &c.ExpiryEncrypted, &keyID, &c.CVVHash, &c.HMAC,
+		&c.MaskedNumber, &c.PaymentSystem, &c.Product, &c.PINHash, &c.PINAttempts, &c.Kind, &spendCap, &validUntil,
+		&c.Status, &replacedBy, &c.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	c.KeyID = nullIntPtr(keyID)
+	c.ReplacedBy = nullIntPtr(replacedBy)
+	c.SpendCap = nullFloatPtr(spendCap)
+	if validUntil.Valid {
+		c.ValidUntil = &validUntil.Time
+	}
+	return c, nil
+}
+
+func (r *cardRepo) CreateTx(tx *sql.Tx, c *model.Card) error {
 	query := `
-        INSERT INTO cards(account_id, number_encrypted, expiry_encrypted, cvv_hash, hmac)
-        VALUES($1, $2, $3, $4, $5)
-        RETURNING id, created_at
+        INSERT INTO cards(account_id, number_encrypted, expiry_encrypted, key_id, cvv_hash, hmac, masked_number,
+                          payment_system, product, kind, spend_cap, valid_until)
+        VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+        RETURNING id, status, created_at
     `
-	return r.db.QueryRow(query,
-		c.AccountID, c.NumberEncrypted, c.ExpiryEncrypted, c.CVVHash, c.HMAC,
-	).Scan(&c.ID, &c.CreatedAt)
+	if c.Kind == "" {
+		c.Kind = model.CardKindPhysical
+	}
+	err := tx.QueryRow(query,
+		c.AccountID, c.NumberEncrypted, c.ExpiryEncrypted, c.KeyID, c.CVVHash, c.HMAC, c.MaskedNumber,
+		c.PaymentSystem, c.Product, c.Kind, c.SpendCap, c.ValidUntil,
+	).Scan(&c.ID, &c.Status, &c.CreatedAt)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrCardNumberTaken
+	}
+	return err
 }
 
 func (r *cardRepo) ListByAccount(accountID int) ([]*model.Card, error) {
+	query := `SELECT ` + cardColumns + ` FROM cards WHERE account_id = $1 ORDER BY id`
+	return r.list(query, accountID)
+}
+
+func (r *cardRepo) ListByStatus(status string) ([]*model.Card, error) {
+	query := `SELECT ` + cardColumns + ` FROM cards WHERE status = $1 ORDER BY id`
+	return r.list(query, status)
+}
+
+func (r *cardRepo) GetByID(id int) (*model.Card, error) {
+	query := `SELECT ` + cardColumns + ` FROM cards WHERE id = $1`
+	c, err := scanCard(r.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrCardNotFound
+	}
+	return c, err
+}
+
+func (r *cardRepo) GetByHMAC(mac string) (*model.Card, error) {
+	query := `SELECT ` + cardColumns + ` FROM cards WHERE hmac = $1 ORDER BY id DESC LIMIT 1`
+	c, err := scanCard(r.db.QueryRow(query, mac))
+	if err == sql.ErrNoRows {
+		return nil, ErrCardNotFound
+	}
+	return c, err
+}
+
+func (r *cardRepo) UpdateStatus(c *model.Card, from []string, status string) error {
 	query := `
-        SELECT id, account_id, number_encrypted, expiry_encrypted, cvv_hash, hmac, created_at
-        FROM cards WHERE account_id = $1
+        UPDATE cards SET status = $1, status_changed_at = now()
+        WHERE id = $2 AND status = ANY($3)
     `
-	rows, err := r.db.Query(query, accountID)
+	res, err := r.db.Exec(query, status, c.ID, pq.Array(from))
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer rows.Close()
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return ErrCardStatus
+	}
+	c.Status = status
+	return nil
+}
 
-	var cards []*model.Card
-	for rows.Next() {
-		c := &model.Card{}
-		if err := rows.Scan(&c.ID, &c.AccountID, &c.NumberEncrypted, &c.ExpiryEncrypted, &c.CVVHash, &c.HMAC, &c.CreatedAt); err != nil {
-			return nil, err
-		}
-		cards = append(cards, c)
+func (r *cardRepo) LockActiveTx(tx *sql.Tx, c *model.Card) error {
+	var id int
+	err := tx.QueryRow(`SELECT id FROM cards WHERE id = $1 AND status = 'active' FOR UPDATE`, c.ID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrCardStatus
 	}
-	return cards, rows.Err()
+	return err
 }
 
-func (r *cardRepo) GetByID(id int) (*model.Card, error) {
-	c := &model.Card{}
+func (r *cardRepo) ClaimSingleUseTx(tx *sql.Tx, c *model.Card) error {
 	query := `
-        SELECT id, account_id, number_encrypted, expiry_encrypted, cvv_hash, hmac, created_at
+        UPDATE cards SET status = 'used', status_changed_at = now()
+        WHERE id = $1 AND status = 'active' AND kind = 'single_use'
+    `
+	res, err := tx.Exec(query, c.ID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return ErrCardStatus
+	}
+	c.Status = model.CardUsed
+	return nil
+}
+
+func (r *cardRepo) SetMasked(c *model.Card) error {
+	query := `UPDATE cards SET masked_number = $1, payment_system = $2 WHERE id = $3`
+	_, err := r.db.Exec(query, c.MaskedNumber, c.PaymentSystem, c.ID)
+	return err
+}
+
+func (r *cardRepo) GetControls(cardID int) (*model.CardControls, error) {
+	ctl := &model.CardControls{}
+	var daily, monthly, perTx sql.NullFloat64
+	query := `
+        SELECT daily_limit, monthly_limit, per_transaction_max, allowed_mcc, allowed_countries,
+               online_enabled, offline_enabled
         FROM cards WHERE id = $1
     `
-	err := r.db.QueryRow(query, id).
-		Scan(&c.ID, &c.AccountID, &c.NumberEncrypted, &c.ExpiryEncrypted, &c.CVVHash, &c.HMAC, &c.CreatedAt)
+	err := r.db.QueryRow(query, cardID).Scan(&daily, &monthly, &perTx,
+		pq.Array(&ctl.AllowedMCC), pq.Array(&ctl.AllowedCountries), &ctl.OnlineEnabled, &ctl.OfflineEnabled)
 	if err == sql.ErrNoRows {
 		return nil, ErrCardNotFound
 	}
-	return c, err
+	if err != nil {
+		return nil, err
+	}
+	ctl.DailyLimit = nullFloatPtr(daily)
+	ctl.MonthlyLimit = nullFloatPtr(monthly)
+	ctl.PerTransactionMax = nullFloatPtr(perTx)
+	return ctl, nil
+}
+
+func (r *cardRepo) UpdateControls(cardID int, ctl *model.CardControls) error {
+	query := `
+        UPDATE cards
+        SET daily_limit = $1, monthly_limit = $2, per_transaction_max = $3,
+            allowed_mcc = $4, allowed_countries = $5, online_enabled = $6, offline_enabled = $7
+        WHERE id = $8
+    `
+	_, err := r.db.Exec(query, ctl.DailyLimit, ctl.MonthlyLimit, ctl.PerTransactionMax,
+		pq.Array(ctl.AllowedMCC), pq.Array(ctl.AllowedCountries), ctl.OnlineEnabled, ctl.OfflineEnabled, cardID)
+	return err
+}
+
+func (r *cardRepo) SetPIN(c *model.Card, pinHash string) error {
+	query := `
+        UPDATE cards
+        SET pin_hash = $1, pin_attempts = 0,
+            status = CASE WHEN status = 'blocked_pin' THEN 'active' ELSE status END
+        WHERE id = $2
+        RETURNING status
+    `
+	if err := r.db.QueryRow(query, pinHash, c.ID).Scan(&c.Status); err != nil {
+		return err
+	}
+	c.PINHash = pinHash
+	c.PINAttempts = 0
+	return nil
+}
+
+func (r *cardRepo) RegisterPINFailure(c *model.Card, maxAttempts int) error {
+	query := `
+        UPDATE cards
+        SET pin_attempts = pin_attempts + 1,
+            status = CASE WHEN pin_attempts + 1 >= $1 AND status = 'active' THEN 'blocked_pin' ELSE status END,
+            status_changed_at = CASE WHEN pin_attempts + 1 >= $1 AND status = 'active' THEN now() ELSE status_changed_at END
+        WHERE id = $2
+        RETURNING pin_attempts, status
+    `
+	return r.db.QueryRow(query, maxAttempts, c.ID).Scan(&c.PINAttempts, &c.Status)
+}
+
+func (r *cardRepo) ResetPINAttempts(c *model.Card) error {
+	_, err := r.db.Exec(`UPDATE cards SET pin_attempts = 0 WHERE id = $1 AND pin_attempts > 0`, c.ID)
+	if err == nil {
+		c.PINAttempts = 0
+	}
+	return err
+}
+
+func (r *cardRepo) ReissueTx(tx *sql.Tx, c *model.Card, from []string, replacedBy int) error {
+	query := `
+        UPDATE cards SET status = 'reissued', replaced_by = $1, status_changed_at = now()
+        WHERE id = $2 AND status = ANY($3)
+    `
+	res, err := tx.Exec(query, replacedBy, c.ID, pq.Array(from))
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return ErrCardStatus
+	}
+	moveTokens := `UPDATE card_tokens SET card_id = $1, updated_at = now() WHERE card_id = $2 AND status = 'active'`
+	if _, err := tx.Exec(moveTokens, replacedBy, c.ID); err != nil {
+		return err
+	}
+	c.Status = model.CardReissued
+	c.ReplacedBy = &replacedBy
+	return nil
+}
+
+func (r *cardRepo) ListByStaleKey(keyID, afterID, limit int) ([]*model.Card, error) {
+	query := `
+        SELECT ` + cardColumns + `
+        FROM cards
+        WHERE (key_id IS NULL OR key_id <> $1) AND id > $2
+        ORDER BY id LIMIT $3
+    `
+	return r.list(query, keyID, afterID, limit)
+}
+
+func (r *cardRepo) CountByStaleKey(keyID int) (int, error) {
+	var n int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM cards WHERE key_id IS NULL OR key_id <> $1`, keyID).Scan(&n)
+	return n, err
+}
+
+func (r *cardRepo) SetEncryption(c *model.Card, keyID int, numberEnc, expiryEnc []byte) error {
+	query := `
+        UPDATE cards SET number_encrypted = $1, expiry_encrypted = $2, key_id = $3
+        WHERE id = $4 AND key_id IS NOT DISTINCT FROM $5
+    `
+	res, err := r.db.Exec(query, numberEnc, expiryEnc, keyID, c.ID, c.KeyID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return ErrCardKeyChanged
+	}
+	c.NumberEncrypted = numberEnc
+	c.ExpiryEncrypted = expiryEnc
+	c.KeyID = &keyID
+	return nil
+}
+
+func (r *cardRepo) list(query string, args ...interface{}) ([]*model.Card, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cards []*model.Card
+	for rows.Next() {
+		c, err := scanCard(rows)
+		if err != nil {
+			return nil, err
+		}
+		cards = append(cards, c)
+	}
+	return cards, rows.Err()
 }
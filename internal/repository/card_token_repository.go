@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"Bank/internal/model"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrTokenNotFound = errors.New("token not found")
+	// ErrTokenTaken — сгенерированный токен уже выдан или у карты уже есть
+	// действующий токен той же области и формата.
+	ErrTokenTaken = errors.New("token already exists")
+)
+
+type CardTokenRepository interface {
+	Create(t *model.CardToken) error
+	GetByToken(token string) (*model.CardToken, error)
+	// GetActive возвращает действующий токен карты для области и формата.
+	GetActive(cardID int, scope, format string) (*model.CardToken, error)
+	ListByCard(cardID int) ([]*model.CardToken, error)
+	Delete(t *model.CardToken) error
+}
+
+type cardTokenRepo struct {
+	db *sql.DB
+}
+
+func NewCardTokenRepository(db *sql.DB) CardTokenRepository {
+	return &cardTokenRepo{db: db}
+}
+
+const cardTokenColumns = `id, card_id, token, format, scope, status, created_at, updated_at`
+
+func scanCardToken(row interface{ Scan(...interface{}) error }) (*model.CardToken, error) {
+	t := &model.CardToken{}
+	err := row.Scan(&t.ID, &t.CardID, &t.Token, &t.Format, &t.Scope, &t.Status, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (r *cardTokenRepo) Create(t *model.CardToken) error {
+	query := `
+        INSERT INTO card_tokens(card_id, token, format, scope)
+        VALUES($1, $2, $3, $4)
+        RETURNING id, status, created_at, updated_at
+    `
+	err := r.db.QueryRow(query, t.CardID, t.Token, t.Format, t.Scope).
+		Scan(&t.ID, &t.Status, &t.CreatedAt, &t.UpdatedAt)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrTokenTaken
+	}
+	return err
+}
+
+func (r *cardTokenRepo) GetByToken(token string) (*model.CardToken, error) {
+	query := `SELECT ` + cardTokenColumns + ` FROM card_tokens WHERE token = $1`
+	t, err := scanCardToken(r.db.QueryRow(query, token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTokenNotFound
+	}
+	return t, err
+}
+
+func (r *cardTokenRepo) GetActive(cardID int, scope, format string) (*model.CardToken, error) {
+	query := `
+        SELECT ` + cardTokenColumns + `
+        FROM card_tokens
+        WHERE card_id = $1 AND scope = $2 AND format = $3 AND status = 'active'
+    `
+	t, err := scanCardToken(r.db.QueryRow(query, cardID, scope, format))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTokenNotFound
+	}
+	return t, err
+}
+
+func (r *cardTokenRepo) ListByCard(cardID int) ([]*model.CardToken, error) {
+	query := `SELECT ` + cardTokenColumns + ` FROM card_tokens WHERE card_id = $1 ORDER BY id`
+	rows, err := r.db.Query(query, cardID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*model.CardToken
+	for rows.Next() {
+		t, err := scanCardToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, t)
+	}
+	return list, rows.Err()
+}
+
+func (r *cardTokenRepo) Delete(t *model.CardToken) error {
+	query := `
+        UPDATE card_tokens SET status = 'deleted', updated_at = now()
+        WHERE id = $1 AND status = 'active'
+        RETURNING updated_at
+    `
+	err := r.db.QueryRow(query, t.ID).Scan(&t.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrTokenNotFound
+	}
+	if err != nil {
+		return err
+	}
+	t.Status = model.TokenDeleted
+	return nil
+}
@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"Bank/internal/model"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var (
+	ErrCardTransactionNotFound = errors.New("card transaction not found")
+	ErrCardTransactionClosed   = errors.New("card transaction is already settled or reversed")
+)
+
+type CardTransactionRepository interface {
+	Create(t *model.CardTransaction) error
+	CreateTx(tx *sql.Tx, t *model.CardTransaction) error
+	// GetAuthorized ищет одобренную и ещё не рассчитанную операцию карты по коду авторизации.
+	GetAuthorized(cardID int, approvalCode string) (*model.CardTransaction, error)
+	// ListByCard возвращает операции карты от новых к старым.
+	ListByCard(cardID, limit, offset int) ([]*model.CardTransaction, error)
+	// SumByCardSinceTx — сумма одобренных и рассчитанных покупок по карте с момента since.
+	SumByCardSinceTx(tx *sql.Tx, cardID int, since time.Time) (float64, error)
+	// Settle отмечает расчёт операции и связывает её с проводкой по счёту.
+	Settle(t *model.CardTransaction, capturedAmount float64, transactionID int) error
+	Reverse(t *model.CardTransaction) error
+}
+
+type cardTransactionRepo struct {
+	db *sql.DB
+}
+
+func NewCardTransactionRepository(db *sql.DB) CardTransactionRepository {
+	return &cardTransactionRepo{db: db}
+}
+
+const cardTransactionColumns = `id, card_id, hold_id, transaction_id, stan, rrn, COALESCE(approval_code, ''), response_code,
+        amount, captured_amount, merchant_id, merchant_name, merchant_city, merchant_country, mcc, status,
+        created_at, updated_at`
+
+func scanCardTransaction(row interface{ Scan(...interface{}) error }) (*model.CardTransaction, error) {
+	t := &model.CardTransaction{}
+	var card, hold, txID sql.NullInt64
+	err := row.Scan(&t.ID, &card, &hold, &txID, &t.STAN, &t.RRN, &t.ApprovalCode, &t.ResponseCode,
+		&t.Amount, &t.CapturedAmount, &t.MerchantID, &t.MerchantName, &t.MerchantCity, &t.MerchantCountry, &t.MCC,
+		&t.Status, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	t.CardID = nullIntPtr(card)
+	t.HoldID = nullIntPtr(hold)
+	t.TransactionID = nullIntPtr(txID)
+	return t, nil
+}
+
+func (r *cardTransactionRepo) Create(t *model.CardTransaction) error {
+	return r.create(r.db, t)
+}
+
+func (r *cardTransactionRepo) CreateTx(tx *sql.Tx, t *model.CardTransaction) error {
+	return r.create(tx, t)
+}
+
+func (r *cardTransactionRepo) create(q querier, t *model.CardTransaction) error {
+	query := `
+        INSERT INTO card_transactions(card_id, hold_id, stan, rrn, approval_code, response_code, amount,
+                                      merchant_id, merchant_name, merchant_city, merchant_country, mcc, status)
+        VALUES($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8, $9, $10, $11, $12, $13)
+        RETURNING id, created_at, updated_at
+    `
+	return q.QueryRow(query,
+		t.CardID, t.HoldID, t.STAN, t.RRN, t.ApprovalCode, t.ResponseCode, t.Amount,
+		t.MerchantID, t.MerchantName, t.MerchantCity, t.MerchantCountry, t.MCC, t.Status,
+	).Scan(&t.ID, &t.CreatedAt, &t.UpdatedAt)
+}
+
+func (r *cardTransactionRepo) GetAuthorized(cardID int, approvalCode string) (*model.CardTransaction, error) {
+	query := `
+        SELECT ` + cardTransactionColumns + `
+        FROM card_transactions
+        WHERE card_id = $1 AND approval_code = $2 AND status = 'authorized'
+    `
+	t, err := scanCardTransaction(r.db.QueryRow(query, cardID, approvalCode))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCardTransactionNotFound
+	}
+	return t, err
+}
+
+func (r *cardTransactionRepo) ListByCard(cardID, limit, offset int) ([]*model.CardTransaction, error) {
+	query := `
+        SELECT ` + cardTransactionColumns + `
+        FROM card_transactions
+        WHERE card_id = $1
+        ORDER BY created_at DESC, id DESC
+        LIMIT $2 OFFSET $3
+    `
+	rows, err := r.db.Query(query, cardID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []*model.CardTransaction{}
+	for rows.Next() {
+		t, err := scanCardTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, t)
+	}
+	return list, rows.Err()
+}
+
+func (r *cardTransactionRepo) SumByCardSinceTx(tx *sql.Tx, cardID int, since time.Time) (float64, error) {
+	query := `
+        SELECT COALESCE(SUM(CASE WHEN status = 'settled' THEN captured_amount ELSE amount END), 0)
+        FROM card_transactions
+        WHERE card_id = $1 AND status IN ('authorized', 'settled') AND created_at >= $2
+    `
+	var sum float64
+	err := tx.QueryRow(query, cardID, since).Scan(&sum)
+	return sum, err
+}
+
+func (r *cardTransactionRepo) Settle(t *model.CardTransaction, capturedAmount float64, transactionID int) error {
+	query := `
+        UPDATE card_transactions
+        SET status = 'settled', captured_amount = $1, transaction_id = $2, updated_at = now()
+        WHERE id = $3 AND status = 'authorized'
+    `
+	res, err := r.db.Exec(query, capturedAmount, transactionID, t.ID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return ErrCardTransactionClosed
+	}
+	t.Status = model.CardTxSettled
+	t.CapturedAmount = capturedAmount
+	t.TransactionID = &transactionID
+	return nil
+}
+
+func (r *cardTransactionRepo) Reverse(t *model.CardTransaction) error {
+	query := `
+        UPDATE card_transactions
+        SET status = 'reversed', updated_at = now()
+        WHERE id = $1 AND status = 'authorized'
+    `
+	res, err := r.db.Exec(query, t.ID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return ErrCardTransactionClosed
+	}
+	t.Status = model.CardTxReversed
+	return nil
+}
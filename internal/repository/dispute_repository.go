@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"Bank/internal/model"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrDisputeNotFound      = errors.New("dispute not found")
+	ErrDisputeAlreadyOpen   = errors.New("transaction already has an open dispute")
+	ErrDisputeStatusChanged = errors.New("dispute is not in the expected status")
+)
+
+type DisputeRepository interface {
+	Create(d *model.Dispute) error
+	GetByID(id int) (*model.Dispute, error)
+	ListByUser(userID int) ([]*model.Dispute, error)
+	// ListByStatus возвращает споры в статусе status; пустой status — все споры.
+	ListByStatus(status string) ([]*model.Dispute, error)
+	// UpdateStatus переводит спор в status, только если текущий статус входит в from.
+	UpdateStatus(d *model.Dispute, from []string, status, resolution string) error
+}
+
+type disputeRepo struct {
+	db *sql.DB
+}
+
+func NewDisputeRepository(db *sql.DB) DisputeRepository {
+	return &disputeRepo{db: db}
+}
+
+const disputeColumns = `id, transaction_id, user_id, reason, status, resolution, created_at, updated_at`
+
+func scanDispute(row interface{ Scan(...interface{}) error }) (*model.Dispute, error) {
+	d := &model.Dispute{}
+	err := row.Scan(&d.ID, &d.TransactionID, &d.UserID, &d.Reason, &d.Status, &d.Resolution, &d.CreatedAt, &d.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (r *disputeRepo) Create(d *model.Dispute) error {
+	query := `
+        INSERT INTO disputes(transaction_id, user_id, reason)
+        VALUES($1, $2, $3)
+        RETURNING id, status, resolution, created_at, updated_at
+    `
+	err := r.db.QueryRow(query, d.TransactionID, d.UserID, d.Reason).
+		Scan(&d.ID, &d.Status, &d.Resolution, &d.CreatedAt, &d.UpdatedAt)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrDisputeAlreadyOpen
+	}
+	return err
+}
+
+func (r *disputeRepo) GetByID(id int) (*model.Dispute, error) {
+	query := `SELECT ` + disputeColumns + ` FROM disputes WHERE id = $1`
+	d, err := scanDispute(r.db.QueryRow(query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrDisputeNotFound
+	}
+	return d, err
+}
+
+func (r *disputeRepo) ListByUser(userID int) ([]*model.Dispute, error) {
+	query := `SELECT ` + disputeColumns + ` FROM disputes WHERE user_id = $1 ORDER BY created_at DESC`
+	return r.list(query, userID)
+}
+
+func (r *disputeRepo) ListByStatus(status string) ([]*model.Dispute, error) {
+	query := `
+        SELECT ` + disputeColumns + ` FROM disputes
+        WHERE $1 = '' OR status = $1
+        ORDER BY created_at
+    `
+	return r.list(query, status)
+}
+
+func (r *disputeRepo) UpdateStatus(d *model.Dispute, from []string, status, resolution string) error {
+	query := `
+        UPDATE disputes
+        SET status = $1, resolution = $2, updated_at = now()
+        WHERE id = $3 AND status = ANY($4)
+        RETURNING updated_at
+    `
+	err := r.db.QueryRow(query, status, resolution, d.ID, pq.Array(from)).Scan(&d.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrDisputeStatusChanged
+	}
+	if err != nil {
+		return err
+	}
+	d.Status = status
+	d.Resolution = resolution
+	return nil
+}
+
+func (r *disputeRepo) list(query string, args ...interface{}) ([]*model.Dispute, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*model.Dispute
+	for rows.Next() {
+		d, err := scanDispute(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, d)
+	}
+	return list, rows.Err()
+}
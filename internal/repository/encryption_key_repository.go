@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"Bank/internal/model"
+	"database/sql"
+	"errors"
+)
+
+var ErrKeyNotFound = errors.New("encryption key not found")
+
+type EncryptionKeyRepository interface {
+	GetByID(id int) (*model.EncryptionKey, error)
+	GetActive() (*model.EncryptionKey, error)
+	// Rotate выводит текущий активный ключ из оборота и делает активным новый.
+	Rotate(k *model.EncryptionKey) error
+}
+
+type encryptionKeyRepo struct {
+	db *sql.DB
+}
+
+func NewEncryptionKeyRepository(db *sql.DB) EncryptionKeyRepository {
+	return &encryptionKeyRepo{db: db}
+}
+
+const encryptionKeyColumns = `id, wrapped_key, status, created_at, retired_at`
+
+func scanEncryptionKey(row interface{ Scan(...interface{}) error }) (*model.EncryptionKey, error) {
+	k := &model.EncryptionKey{}
+	var retiredAt sql.NullTime
+	if err := row.Scan(&k.ID, &k.WrappedKey, &k.Status, &k.CreatedAt, &retiredAt); err != nil {
+		return nil, err
+	}
+	if retiredAt.Valid {
+		k.RetiredAt = &retiredAt.Time
+	}
+	return k, nil
+}
+
+func (r *encryptionKeyRepo) GetByID(id int) (*model.EncryptionKey, error) {
+	query := `SELECT ` + encryptionKeyColumns + ` FROM encryption_keys WHERE id = $1`
+	k, err := scanEncryptionKey(r.db.QueryRow(query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrKeyNotFound
+	}
+	return k, err
+}
+
+func (r *encryptionKeyRepo) GetActive() (*model.EncryptionKey, error) {
+	query := `SELECT ` + encryptionKeyColumns + ` FROM encryption_keys WHERE status = 'active'`
+	k, err := scanEncryptionKey(r.db.QueryRow(query))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrKeyNotFound
+	}
+	return k, err
+}
+
+func (r *encryptionKeyRepo) Rotate(k *model.EncryptionKey) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	retire := `UPDATE encryption_keys SET status = 'retired', retired_at = now() WHERE status = 'active'`
+	if _, err := tx.Exec(retire); err != nil {
+		tx.Rollback()
+		return err
+	}
+	insert := `
+        INSERT INTO encryption_keys(wrapped_key)
+        VALUES($1)
+        RETURNING id, status, created_at
+    `
+	if err := tx.QueryRow(insert, k.WrappedKey).Scan(&k.ID, &k.Status, &k.CreatedAt); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"Bank/internal/model"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var ErrFeeScheduleNotFound = errors.New("fee schedule not found")
+
+type FeeRepository interface {
+	GetByOperation(operation string) (*model.FeeSchedule, error)
+	List() ([]*model.FeeSchedule, error)
+	UsageCount(userID int, operation string, period time.Time) (int, error)
+	// TakeFreeUsage засчитывает бесплатную операцию, если за период их меньше
+	// freePerMonth; false — квота уже исчерпана.
+	TakeFreeUsage(tx *sql.Tx, userID int, operation string, period time.Time, freePerMonth int) (bool, error)
+}
+
+type feeRepo struct {
+	db *sql.DB
+}
+
+func NewFeeRepository(db *sql.DB) FeeRepository {
+	return &feeRepo{db: db}
+}
+
+const feeScheduleColumns = `id, operation, rule_type, flat_amount, percent, min_fee, max_fee, tiers, free_per_month, active, created_at`
+
+func scanFeeSchedule(row interface{ Scan(...interface{}) error }) (*model.FeeSchedule, error) {
+	f := &model.FeeSchedule{}
+	var tiers []byte
+	err := row.Scan(&f.ID, &f.Operation, &f.RuleType, &f.FlatAmount, &f.Percent, &f.MinFee, &f.MaxFee,
+		&tiers, &f.FreePerMonth, &f.Active, &f.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if len(tiers) > 0 {
+		if err := json.Unmarshal(tiers, &f.Tiers); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+func (r *feeRepo) GetByOperation(operation string) (*model.FeeSchedule, error) {
+	query := `SELECT ` + feeScheduleColumns + ` FROM fee_schedules WHERE operation = $1 AND active`
+	f, err := scanFeeSchedule(r.db.QueryRow(query, operation))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrFeeScheduleNotFound
+	}
+	return f, err
+}
+
+func (r *feeRepo) List() ([]*model.FeeSchedule, error) {
+	query := `SELECT ` + feeScheduleColumns + ` FROM fee_schedules WHERE active ORDER BY operation`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*model.FeeSchedule
+	for rows.Next() {
+		f, err := scanFeeSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, f)
+	}
+	return list, rows.Err()
+}
+
+// period — колонка DATE; передаём календарную дату строкой, чтобы она не
+// сдвигалась при приведении к часовому поясу сессии БД.
+func (r *feeRepo) UsageCount(userID int, operation string, period time.Time) (int, error) {
+	var n int
+	query := `SELECT count FROM fee_usage WHERE user_id = $1 AND operation = $2 AND period = $3`
+	err := r.db.QueryRow(query, userID, operation, period.Format(time.DateOnly)).Scan(&n)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return n, err
+}
+
+func (r *feeRepo) TakeFreeUsage(tx *sql.Tx, userID int, operation string, period time.Time, freePerMonth int) (bool, error) {
+	query := `
+        INSERT INTO fee_usage(user_id, operation, period, count)
+        VALUES($1, $2, $3, 1)
+        ON CONFLICT (user_id, operation, period) DO UPDATE SET count = fee_usage.count + 1
+        WHERE fee_usage.count < $4
+    `
+	res, err := tx.Exec(query, userID, operation, period.Format(time.DateOnly), freePerMonth)
+	if err != nil {
+		return false, err
+	}
+	rows, _ := res.RowsAffected()
+	return rows > 0, nil
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"Bank/internal/model"
+	"database/sql"
+	"errors"
+)
+
+var (
+	ErrHoldNotFound = errors.New("hold not found")
+	ErrHoldNotOpen  = errors.New("hold is not active")
+	// ErrHoldNotPlaced возвращается, если доступного баланса не хватило на холд.
+	ErrHoldNotPlaced = errors.New("available balance is too low to place hold")
+)
+
+type HoldRepository interface {
+	// CreateTx блокирует строку счёта и ставит холд, если доступный баланс
+	// покрывает сумму; параллельные холды по счёту выстраиваются в очередь.
+	CreateTx(tx *sql.Tx, h *model.Hold) error
+	GetByID(id int) (*model.Hold, error)
+	ListByAccount(accountID int) ([]*model.Hold, error)
+	// CaptureTx закрывает активный холд списанием amount.
+	CaptureTx(tx *sql.Tx, id int, amount float64, transactionID int) error
+	Release(id int) error
+	// ExpireDue помечает истёкшие холды и возвращает их количество.
+	ExpireDue() (int64, error)
+}
+
+type holdRepo struct {
+	db *sql.DB
+}
+
+func NewHoldRepository(db *sql.DB) HoldRepository {
+	return &holdRepo{db: db}
+}
+
+const holdColumns = `id, account_id, amount, captured_amount, status, source, description, transaction_id, expires_at, created_at, updated_at`
+
+func scanHold(row interface{ Scan(...interface{}) error }) (*model.Hold, error) {
+	h := &model.Hold{}
+	var txID sql.NullInt64
+	err := row.Scan(&h.ID, &h.AccountID, &h.Amount, &h.CapturedAmount, &h.Status, &h.Source, &h.Description,
+		&txID, &h.ExpiresAt, &h.CreatedAt, &h.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	h.TransactionID = nullIntPtr(txID)
+	return h, nil
+}
+
+func (r *holdRepo) CreateTx(tx *sql.Tx, h *model.Hold) error {
+	var id int
+	err := tx.QueryRow(`SELECT id FROM accounts WHERE id = $1 FOR UPDATE`, h.AccountID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrAccountNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	query := `
+        INSERT INTO holds(account_id, amount, source, description, expires_at)
+        SELECT a.id, $2, $5, $3, $4
+        FROM accounts a
+        WHERE a.id = $1
+          AND a.balance - COALESCE((SELECT SUM(amount) FROM holds WHERE account_id = a.id AND status = 'active'), 0) >= $2
+        RETURNING id, status, created_at, updated_at
+    `
+	err = tx.QueryRow(query, h.AccountID, h.Amount, h.Description, h.ExpiresAt, h.Source).
+		Scan(&h.ID, &h.Status, &h.CreatedAt, &h.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrHoldNotPlaced
+	}
+	return err
+}
+
+func (r *holdRepo) GetByID(id int) (*model.Hold, error) {
+	query := `SELECT ` + holdColumns + ` FROM holds WHERE id = $1`
+	h, err := scanHold(r.db.QueryRow(query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrHoldNotFound
+	}
+	return h, err
+}
+
+func (r *holdRepo) ListByAccount(accountID int) ([]*model.Hold, error) {
+	query := `SELECT ` + holdColumns + ` FROM holds WHERE account_id = $1 ORDER BY created_at DESC`
+	rows, err := r.db.Query(query, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*model.Hold
+	for rows.Next() {
+		h, err := scanHold(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, h)
+	}
+	return list, rows.Err()
+}
+
+func (r *holdRepo) CaptureTx(tx *sql.Tx, id int, amount float64, transactionID int) error {
+	query := `
+        UPDATE holds
+        SET status = 'captured', captured_amount = $1, transaction_id = $2, updated_at = now()
+        WHERE id = $3 AND status = 'active' AND expires_at > now()
+    `
+	res, err := tx.Exec(query, amount, transactionID, id)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return ErrHoldNotOpen
+	}
+	return nil
+}
+
+func (r *holdRepo) Release(id int) error {
+	query := `UPDATE holds SET status = 'released', updated_at = now() WHERE id = $1 AND status = 'active'`
+	res, err := r.db.Exec(query, id)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return ErrHoldNotOpen
+	}
+	return nil
+}
+
+func (r *holdRepo) ExpireDue() (int64, error) {
+	query := `UPDATE holds SET status = 'expired', updated_at = now() WHERE status = 'active' AND expires_at <= now()`
+	res, err := r.db.Exec(query)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"Bank/internal/model"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type LimitRepository interface {
+	ListByUser(userID int) ([]*model.TransactionLimit, error)
+	Upsert(l *model.TransactionLimit) error
+	// SumByUserSince — сумма исходящих операций вида operation по всем счетам пользователя;
+	// для покупок учитываются и одобренные авторизации, ещё не прошедшие клиринг.
+	SumByUserSince(userID int, operation string, since time.Time) (float64, error)
+	// SumByAccountSince — то же по одному счёту.
+	SumByAccountSince(accountID int, operation string, since time.Time) (float64, error)
+	// LockUserTx блокирует строку пользователя: проверки лимитов его операций
+	// в разных транзакциях выполняются по очереди.
+	LockUserTx(tx *sql.Tx, userID int) error
+	SumByUserSinceTx(tx *sql.Tx, userID int, operation string, since time.Time) (float64, error)
+	SumByAccountSinceTx(tx *sql.Tx, accountID int, operation string, since time.Time) (float64, error)
+}
+
+// querier — общее у *sql.DB и *sql.Tx для запросов, выполняемых и вне, и внутри транзакции.
+type querier interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+type limitRepo struct {
+	db *sql.DB
+}
+
+func NewLimitRepository(db *sql.DB) LimitRepository {
+	return &limitRepo{db: db}
+}
+
+func (r *limitRepo) ListByUser(userID int) ([]*model.TransactionLimit, error) {
+	query := `
+        SELECT id, user_id, account_id, operation, daily_limit, monthly_limit, per_operation_max, updated_at
+        FROM transaction_limits WHERE user_id = $1
+        ORDER BY operation, account_id NULLS FIRST
+    `
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*model.TransactionLimit
+	for rows.Next() {
+		l := &model.TransactionLimit{}
+		var accountID sql.NullInt64
+		var daily, monthly, perOp sql.NullFloat64
+		if err := rows.Scan(&l.ID, &l.UserID, &accountID, &l.Operation, &daily, &monthly, &perOp, &l.UpdatedAt); err != nil {
+			return nil, err
+		}
+		l.AccountID = nullIntPtr(accountID)
+		l.DailyLimit = nullFloatPtr(daily)
+		l.MonthlyLimit = nullFloatPtr(monthly)
+		l.PerOperationMax = nullFloatPtr(perOp)
+		list = append(list, l)
+	}
+	return list, rows.Err()
+}
+
+func (r *limitRepo) Upsert(l *model.TransactionLimit) error {
+	query := `
+        INSERT INTO transaction_limits(user_id, account_id, operation, daily_limit, monthly_limit, per_operation_max)
+        VALUES($1, $2, $3, $4, $5, $6)
+        ON CONFLICT (user_id, operation, COALESCE(account_id, 0)) DO UPDATE
+        SET daily_limit = EXCLUDED.daily_limit,
+            monthly_limit = EXCLUDED.monthly_limit,
+            per_operation_max = EXCLUDED.per_operation_max,
+            updated_at = now()
+        RETURNING id, updated_at
+    `
+	return r.db.QueryRow(query,
+		l.UserID, l.AccountID, l.Operation, l.DailyLimit, l.MonthlyLimit, l.PerOperationMax,
+	).Scan(&l.ID, &l.UpdatedAt)
+}
+
+func (r *limitRepo) LockUserTx(tx *sql.Tx, userID int) error {
+	var id int
+	err := tx.QueryRow(`SELECT id FROM users WHERE id = $1 FOR UPDATE`, userID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrUserNotFound
+	}
+	return err
+}
+
+func (r *limitRepo) SumByUserSince(userID int, operation string, since time.Time) (float64, error) {
+	return sumByUserSince(r.db, userID, operation, since)
+}
+
+func (r *limitRepo) SumByUserSinceTx(tx *sql.Tx, userID int, operation string, since time.Time) (float64, error) {
+	return sumByUserSince(tx, userID, operation, since)
+}
+
+func (r *limitRepo) SumByAccountSince(accountID int, operation string, since time.Time) (float64, error) {
+	return sumByAccountSince(r.db, accountID, operation, since)
+}
+
+func (r *limitRepo) SumByAccountSinceTx(tx *sql.Tx, accountID int, operation string, since time.Time) (float64, error) {
+	return sumByAccountSince(tx, accountID, operation, since)
+}
+
+func sumByUserSince(q querier, userID int, operation string, since time.Time) (float64, error) {
+	query := `
+        SELECT COALESCE(SUM(t.amount), 0)
+        FROM transactions t
+        JOIN accounts a ON a.id = t.account_id
+        WHERE a.user_id = $1 AND t.created_at >= $2 AND ` + outgoingFilter(operation)
+	if operation == model.LimitOpPurchase {
+		query = `SELECT (` + query + `) + (
+            SELECT COALESCE(SUM(ct.amount), 0)
+            FROM card_transactions ct
+            JOIN cards c ON c.id = ct.card_id
+            JOIN accounts a ON a.id = c.account_id
+            WHERE a.user_id = $1 AND ` + pendingPurchaseFilter + `)`
+	}
+	var sum float64
+	err := q.QueryRow(query, userID, since).Scan(&sum)
+	return sum, err
+}
+
+func sumByAccountSince(q querier, accountID int, operation string, since time.Time) (float64, error) {
+	query := `
+        SELECT COALESCE(SUM(t.amount), 0)
+        FROM transactions t
+        WHERE t.account_id = $1 AND t.created_at >= $2 AND ` + outgoingFilter(operation)
+	if operation == model.LimitOpPurchase {
+		query = `SELECT (` + query + `) + (
+            SELECT COALESCE(SUM(ct.amount), 0)
+            FROM card_transactions ct
+            JOIN cards c ON c.id = ct.card_id
+            WHERE c.account_id = $1 AND ` + pendingPurchaseFilter + `)`
+	}
+	var sum float64
+	err := q.QueryRow(query, accountID, since).Scan(&sum)
+	return sum, err
+}
+
+// pendingPurchaseFilter — одобренные, но ещё не рассчитанные авторизации:
+// до клиринга по ним есть только холд, проводки card_purchase ещё нет.
+// Рассчитанные попадают в сумму уже как проводки, поэтому не задваиваются.
+const pendingPurchaseFilter = `ct.status = '` + model.CardTxAuthorized + `' AND ct.created_at >= $2`
+
+// outgoingFilter выбирает исходящие операции: у переводов обе проводки
+// имеют тип transfer, списание отличается направлением debit.
+func outgoingFilter(operation string) string {
+	switch operation {
+	case model.LimitOpTransfer:
+		return `t.type = 'transfer' AND t.direction = '` + model.TxDebit + `'`
+	case model.LimitOpPurchase:
+		return `t.type = '` + model.TxCardPurchase + `'`
+	}
+	return `t.type = 'withdraw'`
+}
+
+func nullFloatPtr(n sql.NullFloat64) *float64 {
+	if !n.Valid {
+		return nil
+	}
+	return &n.Float64
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"Bank/internal/model"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var ErrLoginThrottleNotFound = errors.New("login throttle not found")
+
+type LoginThrottleRepository interface {
+	Get(key string) (*model.LoginThrottle, error)
+	// RecordFailure атомарно увеличивает счётчик. Если с прошлой неудачи прошло
+	// больше window или блокировка уже истекла, счёт начинается заново.
+	RecordFailure(key string, window time.Duration) (*model.LoginThrottle, error)
+	// Lock блокирует ключ до until; false — ключ уже заблокирован.
+	Lock(key string, until time.Time) (bool, error)
+	Reset(key string) error
+	// DeleteStale удаляет незаблокированные счётчики без неудач после before.
+	DeleteStale(before time.Time) (int64, error)
+}
+
+type loginThrottleRepo struct {
+	db *sql.DB
+}
+
+func NewLoginThrottleRepository(db *sql.DB) LoginThrottleRepository {
+	return &loginThrottleRepo{db: db}
+}
+
+func scanLoginThrottle(row interface{ Scan(...interface{}) error }) (*model.LoginThrottle, error) {
+	t := &model.LoginThrottle{}
+	var lockedUntil sql.NullTime
+	if err := row.Scan(&t.Key, &t.Failures, &t.LastFailureAt, &lockedUntil); err != nil {
+		return nil, err
+	}
+	if lockedUntil.Valid {
+		t.LockedUntil = &lockedUntil.Time
+	}
+	return t, nil
+}
+
+func (r *loginThrottleRepo) Get(key string) (*model.LoginThrottle, error) {
+	t, err := scanLoginThrottle(r.db.QueryRow(
+		`SELECT key, failures, last_failure_at, locked_until FROM login_throttles WHERE key = $1`, key))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrLoginThrottleNotFound
+	}
+	return t, err
+}
+
+func (r *loginThrottleRepo) RecordFailure(key string, window time.Duration) (*model.LoginThrottle, error) {
+	query := `
+        INSERT INTO login_throttles(key, failures, last_failure_at)
+        VALUES($1, 1, now())
+        ON CONFLICT (key) DO UPDATE SET
+            failures = CASE
+                WHEN login_throttles.last_failure_at < now() - make_interval(secs => $2)
+                  OR login_throttles.locked_until < now() THEN 1
+                ELSE login_throttles.failures + 1
+            END,
+            locked_until = CASE
+                WHEN login_throttles.locked_until < now() THEN NULL
+                ELSE login_throttles.locked_until
+            END,
+            last_failure_at = now()
+        RETURNING key, failures, last_failure_at, locked_until
+    `
+	return scanLoginThrottle(r.db.QueryRow(query, key, window.Seconds()))
+}
+
+func (r *loginThrottleRepo) Lock(key string, until time.Time) (bool, error) {
+	query := `
+        UPDATE login_throttles SET locked_until = $1
+        WHERE key = $2 AND (locked_until IS NULL OR locked_until < now())
+    `
+	res, err := r.db.Exec(query, until, key)
+	if err != nil {
+		return false, err
+	}
+	rows, _ := res.RowsAffected()
+	return rows > 0, nil
+}
+
+func (r *loginThrottleRepo) Reset(key string) error {
+	_, err := r.db.Exec(`DELETE FROM login_throttles WHERE key = $1`, key)
+	return err
+}
+
+func (r *loginThrottleRepo) DeleteStale(before time.Time) (int64, error) {
+	query := `
+        DELETE FROM login_throttles
+        WHERE last_failure_at < $1 AND (locked_until IS NULL OR locked_until < now())
+    `
+	res, err := r.db.Exec(query, before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"Bank/internal/model"
+	"database/sql"
+	"errors"
+)
+
+// ErrResetTokenInvalid — токена нет, он истёк или уже использован.
+var ErrResetTokenInvalid = errors.New("invalid or expired reset token")
+
+type PasswordResetRepository interface {
+	Create(p *model.PasswordReset) error
+	// Consume помечает действующий токен использованным и возвращает его.
+	Consume(tokenHash string) (*model.PasswordReset, error)
+	// InvalidateByUser гасит все неиспользованные токены пользователя.
+	InvalidateByUser(userID int) error
+}
+
+type passwordResetRepo struct {
+	db *sql.DB
+}
+
+func NewPasswordResetRepository(db *sql.DB) PasswordResetRepository {
+	return &passwordResetRepo{db: db}
+}
+
+func (r *passwordResetRepo) Create(p *model.PasswordReset) error {
+	query := `
+        INSERT INTO password_resets(user_id, token_hash, expires_at)
+        VALUES($1, $2, $3)
+        RETURNING id, created_at
+    `
+	return r.db.QueryRow(query, p.UserID, p.TokenHash, p.ExpiresAt).Scan(&p.ID, &p.CreatedAt)
+}
+
+func (r *passwordResetRepo) Consume(tokenHash string) (*model.PasswordReset, error) {
+	p := &model.PasswordReset{TokenHash: tokenHash}
+	var usedAt sql.NullTime
+	query := `
+        UPDATE password_resets SET used_at = now()
+        WHERE token_hash = $1 AND used_at IS NULL AND expires_at > now()
+        RETURNING id, user_id, expires_at, used_at, created_at
+    `
+	err := r.db.QueryRow(query, tokenHash).Scan(&p.ID, &p.UserID, &p.ExpiresAt, &usedAt, &p.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrResetTokenInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	if usedAt.Valid {
+		p.UsedAt = &usedAt.Time
+	}
+	return p, nil
+}
+
+func (r *passwordResetRepo) InvalidateByUser(userID int) error {
+	_, err := r.db.Exec(`UPDATE password_resets SET used_at = now() WHERE user_id = $1 AND used_at IS NULL`, userID)
+	return err
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"Bank/internal/model"
+	"database/sql"
+	"errors"
+)
+
+var (
+	ErrPayeeNotFound = errors.New("payee not found")
+	ErrPayeeExists   = errors.New("payee with given nickname already exists")
+)
+
+type PayeeRepository interface {
+	Create(p *model.Payee) error
+	GetByID(id int) (*model.Payee, error)
+	ListByUser(userID int) ([]*model.Payee, error)
+	Update(p *model.Payee) error
+	Delete(userID, id int) error
+}
+
+type payeeRepo struct {
+	db *sql.DB
+}
+
+func NewPayeeRepository(db *sql.DB) PayeeRepository {
+	return &payeeRepo{db: db}
+}
+
+const payeeColumns = `id, user_id, nickname, to_account_id, recipient_type, recipient, default_amount, description, created_at`
+
+func scanPayee(row interface{ Scan(...interface{}) error }) (*model.Payee, error) {
+	p := &model.Payee{}
+	var toAccount sql.NullInt64
+	var rType, rcpt sql.NullString
+	var amount sql.NullFloat64
+	if err := row.Scan(&p.ID, &p.UserID, &p.Nickname, &toAccount, &rType, &rcpt, &amount, &p.Description, &p.CreatedAt); err != nil {
+		return nil, err
+	}
+	if toAccount.Valid {
+		id := int(toAccount.Int64)
+		p.ToAccountID = &id
+	}
+	p.RecipientType = rType.String
+	p.Recipient = rcpt.String
+	if amount.Valid {
+		p.DefaultAmount = &amount.Float64
+	}
+	return p, nil
+}
+
+func (r *payeeRepo) Create(p *model.Payee) error {
+	query := `
+        INSERT INTO payees(user_id, nickname, to_account_id, recipient_type, recipient, default_amount, description)
+        VALUES($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), $6, $7)
+        ON CONFLICT (user_id, nickname) DO NOTHING
+        RETURNING id, created_at
+    `
+	err := r.db.QueryRow(query,
+		p.UserID, p.Nickname, p.ToAccountID, p.RecipientType, p.Recipient, p.DefaultAmount, p.Description,
+	).Scan(&p.ID, &p.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrPayeeExists
+	}
+	return err
+}
+
+func (r *payeeRepo) GetByID(id int) (*model.Payee, error) {
+	query := `SELECT ` + payeeColumns + ` FROM payees WHERE id = $1`
+	p, err := scanPayee(r.db.QueryRow(query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPayeeNotFound
+	}
+	return p, err
+}
+
+func (r *payeeRepo) ListByUser(userID int) ([]*model.Payee, error) {
+	query := `SELECT ` + payeeColumns + ` FROM payees WHERE user_id = $1 ORDER BY nickname`
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*model.Payee
+	for rows.Next() {
+		p, err := scanPayee(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, p)
+	}
+	return list, rows.Err()
+}
+
+func (r *payeeRepo) Update(p *model.Payee) error {
+	query := `
+        UPDATE payees
+        SET nickname = $1, to_account_id = $2, recipient_type = NULLIF($3, ''), recipient = NULLIF($4, ''),
+            default_amount = $5, description = $6
+        WHERE id = $7 AND user_id = $8
+    `
+	res, err := r.db.Exec(query,
+		p.Nickname, p.ToAccountID, p.RecipientType, p.Recipient, p.DefaultAmount, p.Description, p.ID, p.UserID,
+	)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return ErrPayeeNotFound
+	}
+	return nil
+}
+
+func (r *payeeRepo) Delete(userID, id int) error {
+	res, err := r.db.Exec(`DELETE FROM payees WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return ErrPayeeNotFound
+	}
+	return nil
+}
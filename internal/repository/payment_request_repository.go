@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"Bank/internal/model"
+	"database/sql"
+	"errors"
+)
+
+var (
+	ErrPaymentRequestNotFound = errors.New("payment request not found")
+	ErrPaymentRequestNotOpen  = errors.New("payment request is already paid, cancelled or expired")
+)
+
+type PaymentRequestRepository interface {
+	Create(p *model.PaymentRequest) error
+	GetByID(id int) (*model.PaymentRequest, error)
+	ListByUser(userID int) ([]*model.PaymentRequest, error)
+	// Claim переводит открытый запрос в processing, чтобы его нельзя было оплатить дважды.
+	Claim(id int) error
+	// Release возвращает запрос в pending после неудачной оплаты.
+	Release(id int) error
+	MarkPaid(p *model.PaymentRequest) error
+	Cancel(userID, id int) error
+}
+
+type paymentRequestRepo struct {
+	db *sql.DB
+}
+
+func NewPaymentRequestRepository(db *sql.DB) PaymentRequestRepository {
+	return &paymentRequestRepo{db: db}
+}
+
+const paymentRequestColumns = `
+        id, user_id, to_account_id, amount, description, status, expires_at,
+        payer_user_id, debit_transaction_id, credit_transaction_id, paid_at, created_at
+`
+
+func scanPaymentRequest(row interface{ Scan(...interface{}) error }) (*model.PaymentRequest, error) {
+	p := &model.PaymentRequest{}
+	var payer, debit, credit sql.NullInt64
+	var paidAt sql.NullTime
+	err := row.Scan(&p.ID, &p.UserID, &p.ToAccountID, &p.Amount, &p.Description, &p.Status, &p.ExpiresAt,
+		&payer, &debit, &credit, &paidAt, &p.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	p.PayerUserID = nullIntPtr(payer)
+	p.DebitTransactionID = nullIntPtr(debit)
+	p.CreditTransactionID = nullIntPtr(credit)
+	if paidAt.Valid {
+		p.PaidAt = &paidAt.Time
+	}
+	return p, nil
+}
+
+func nullIntPtr(n sql.NullInt64) *int {
+	if !n.Valid {
+		return nil
+	}
+	v := int(n.Int64)
+	return &v
+}
+
+func (r *paymentRequestRepo) Create(p *model.PaymentRequest) error {
+	query := `
+        INSERT INTO payment_requests(user_id, to_account_id, amount, description, status, expires_at)
+        VALUES($1, $2, $3, $4, $5, $6)
+        RETURNING id, created_at
+    `
+	return r.db.QueryRow(query, p.UserID, p.ToAccountID, p.Amount, p.Description, p.Status, p.ExpiresAt).
+		Scan(&p.ID, &p.CreatedAt)
+}
+
+func (r *paymentRequestRepo) GetByID(id int) (*model.PaymentRequest, error) {
+	query := `SELECT ` + paymentRequestColumns + ` FROM payment_requests WHERE id = $1`
+	p, err := scanPaymentRequest(r.db.QueryRow(query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPaymentRequestNotFound
+	}
+	return p, err
+}
+
+func (r *paymentRequestRepo) ListByUser(userID int) ([]*model.PaymentRequest, error) {
+	query := `SELECT ` + paymentRequestColumns + ` FROM payment_requests WHERE user_id = $1 ORDER BY created_at DESC`
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*model.PaymentRequest
+	for rows.Next() {
+		p, err := scanPaymentRequest(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, p)
+	}
+	return list, rows.Err()
+}
+
+func (r *paymentRequestRepo) Claim(id int) error {
+	query := `
+        UPDATE payment_requests SET status = 'processing'
+        WHERE id = $1 AND status = 'pending' AND expires_at > now()
+    `
+	return r.execOpen(query, id)
+}
+
+func (r *paymentRequestRepo) Release(id int) error {
+	query := `UPDATE payment_requests SET status = 'pending' WHERE id = $1 AND status = 'processing'`
+	return r.execOpen(query, id)
+}
+
+func (r *paymentRequestRepo) MarkPaid(p *model.PaymentRequest) error {
+	query := `
+        UPDATE payment_requests
+        SET status = 'paid', payer_user_id = $1, debit_transaction_id = $2, credit_transaction_id = $3, paid_at = now()
+        WHERE id = $4 AND status = 'processing'
+        RETURNING paid_at
+    `
+	err := r.db.QueryRow(query, p.PayerUserID, p.DebitTransactionID, p.CreditTransactionID, p.ID).Scan(&p.PaidAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrPaymentRequestNotOpen
+	}
+	if err == nil {
+		p.Status = model.PaymentRequestPaid
+	}
+	return err
+}
+
+func (r *paymentRequestRepo) Cancel(userID, id int) error {
+	query := `UPDATE payment_requests SET status = 'cancelled' WHERE id = $1 AND user_id = $2 AND status = 'pending'`
+	return r.execOpen(query, id, userID)
+}
+
+func (r *paymentRequestRepo) execOpen(query string, args ...interface{}) error {
+	res, err := r.db.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return ErrPaymentRequestNotOpen
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"Bank/internal/model"
+	"database/sql"
+	"errors"
+)
+
+var (
+	ErrAliasNotFound = errors.New("alias not found")
+	ErrAliasTaken    = errors.New("phone is already registered by another user")
+	// ErrAliasCodeInvalid — кода нет, он истёк или попытки исчерпаны.
+	ErrAliasCodeInvalid = errors.New("invalid or expired confirmation code")
+)
+
+type PhoneAliasRepository interface {
+	// Claim создаёт заявку на номер с кодом подтверждения. Свою неподтверждённую
+	// заявку заменяет, чужую — только с истёкшим кодом, подтверждённый номер — нет.
+	Claim(a *model.PhoneAlias) error
+	// UpdateAccount меняет счёт зачисления у подтверждённого номера.
+	UpdateAccount(userID int, phone string, accountID int) error
+	GetByPhone(phone string) (*model.PhoneAlias, error)
+	// GetVerified возвращает номер, только если он подтверждён.
+	GetVerified(phone string) (*model.PhoneAlias, error)
+	// TakeCodeAttempt расходует попытку ввода кода и возвращает хеш кода.
+	TakeCodeAttempt(userID int, phone string, maxAttempts int) (string, error)
+	Verify(userID int, phone string) error
+	ListByUser(userID int) ([]*model.PhoneAlias, error)
+	Delete(userID int, phone string) error
+}
+
+type phoneAliasRepo struct {
+	db *sql.DB
+}
+
+func NewPhoneAliasRepository(db *sql.DB) PhoneAliasRepository {
+	return &phoneAliasRepo{db: db}
+}
+
+const phoneAliasColumns = `phone, user_id, account_id, verified_at, code_hash, code_expires_at, created_at`
+
+func scanPhoneAlias(row interface{ Scan(...interface{}) error }) (*model.PhoneAlias, error) {
+	a := &model.PhoneAlias{}
+	var verifiedAt, codeExpiresAt sql.NullTime
+	if err := row.Scan(&a.Phone, &a.UserID, &a.AccountID, &verifiedAt, &a.CodeHash, &codeExpiresAt, &a.CreatedAt); err != nil {
+		return nil, err
+	}
+	if verifiedAt.Valid {
+		a.VerifiedAt = &verifiedAt.Time
+	}
+	if codeExpiresAt.Valid {
+		a.CodeExpiresAt = &codeExpiresAt.Time
+	}
+	return a, nil
+}
+
+func (r *phoneAliasRepo) Claim(a *model.PhoneAlias) error {
+	query := `
+        INSERT INTO phone_aliases(phone, user_id, account_id, code_hash, code_expires_at)
+        VALUES($1, $2, $3, $4, $5)
+        ON CONFLICT (phone) DO UPDATE
+        SET user_id = EXCLUDED.user_id, account_id = EXCLUDED.account_id,
+            code_hash = EXCLUDED.code_hash, code_expires_at = EXCLUDED.code_expires_at,
+            code_attempts = 0, created_at = now()
+        WHERE phone_aliases.verified_at IS NULL
+          AND (phone_aliases.user_id = EXCLUDED.user_id OR phone_aliases.code_expires_at <= now())
+        RETURNING created_at
+    `
+	err := r.db.QueryRow(query, a.Phone, a.UserID, a.AccountID, a.CodeHash, a.CodeExpiresAt).Scan(&a.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrAliasTaken
+	}
+	return err
+}
+
+func (r *phoneAliasRepo) UpdateAccount(userID int, phone string, accountID int) error {
+	query := `
+        UPDATE phone_aliases SET account_id = $1
+        WHERE phone = $2 AND user_id = $3 AND verified_at IS NOT NULL
+    `
+	res, err := r.db.Exec(query, accountID, phone, userID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return ErrAliasNotFound
+	}
+	return nil
+}
+
+func (r *phoneAliasRepo) GetByPhone(phone string) (*model.PhoneAlias, error) {
+	a, err := scanPhoneAlias(r.db.QueryRow(`SELECT `+phoneAliasColumns+` FROM phone_aliases WHERE phone = $1`, phone))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAliasNotFound
+	}
+	return a, err
+}
+
+func (r *phoneAliasRepo) GetVerified(phone string) (*model.PhoneAlias, error) {
+	a, err := scanPhoneAlias(r.db.QueryRow(
+		`SELECT `+phoneAliasColumns+` FROM phone_aliases WHERE phone = $1 AND verified_at IS NOT NULL`, phone))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAliasNotFound
+	}
+	return a, err
+}
+
+func (r *phoneAliasRepo) TakeCodeAttempt(userID int, phone string, maxAttempts int) (string, error) {
+	query := `
+        UPDATE phone_aliases SET code_attempts = code_attempts + 1
+        WHERE phone = $1 AND user_id = $2 AND verified_at IS NULL
+          AND code_attempts < $3 AND code_expires_at > now()
+        RETURNING code_hash
+    `
+	var hash string
+	err := r.db.QueryRow(query, phone, userID, maxAttempts).Scan(&hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrAliasCodeInvalid
+	}
+	return hash, err
+}
+
+func (r *phoneAliasRepo) Verify(userID int, phone string) error {
+	query := `
+        UPDATE phone_aliases SET verified_at = now(), code_hash = '', code_expires_at = NULL
+        WHERE phone = $1 AND user_id = $2 AND verified_at IS NULL
+    `
+	res, err := r.db.Exec(query, phone, userID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return ErrAliasCodeInvalid
+	}
+	return nil
+}
+
+func (r *phoneAliasRepo) ListByUser(userID int) ([]*model.PhoneAlias, error) {
+	rows, err := r.db.Query(`SELECT `+phoneAliasColumns+` FROM phone_aliases WHERE user_id = $1 ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*model.PhoneAlias
+	for rows.Next() {
+		a, err := scanPhoneAlias(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, a)
+	}
+	return list, rows.Err()
+}
+
+func (r *phoneAliasRepo) Delete(userID int, phone string) error {
+	res, err := r.db.Exec(`DELETE FROM phone_aliases WHERE phone = $1 AND user_id = $2`, phone, userID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return ErrAliasNotFound
+	}
+	return nil
+}
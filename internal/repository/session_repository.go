@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"Bank/internal/model"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrSessionNotActive — сессия отозвана, истекла или refresh-токен уже заменён.
+	ErrSessionNotActive = errors.New("session is not active")
+)
+
+type SessionRepository interface {
+	Create(s *model.Session) error
+	GetByID(id string) (*model.Session, error)
+	// Rotate заменяет хеш refresh-токена, если текущий всё ещё oldHash.
+	Rotate(s *model.Session, oldHash, newHash string, expiresAt time.Time) error
+	Revoke(id string) error
+	// RevokeAllByUser отзывает все активные сессии пользователя.
+	RevokeAllByUser(userID int) (int64, error)
+	// DeleteExpired удаляет сессии, истёкшие или отозванные до before.
+	DeleteExpired(before time.Time) (int64, error)
+}
+
+type sessionRepo struct {
+	db *sql.DB
+}
+
+func NewSessionRepository(db *sql.DB) SessionRepository {
+	return &sessionRepo{db: db}
+}
+
+const sessionColumns = `id, user_id, refresh_hash, previous_hash, ip, user_agent, expires_at, revoked_at, created_at, last_used_at`
+
+func scanSession(row interface{ Scan(...interface{}) error }) (*model.Session, error) {
+	s := &model.Session{}
+	var revokedAt sql.NullTime
+	err := row.Scan(&s.ID, &s.UserID, &s.RefreshHash, &s.PreviousHash, &s.IP, &s.UserAgent,
+		&s.ExpiresAt, &revokedAt, &s.CreatedAt, &s.LastUsedAt)
+	if err != nil {
+		return nil, err
+	}
+	if revokedAt.Valid {
+		s.RevokedAt = &revokedAt.Time
+	}
+	return s, nil
+}
+
+func (r *sessionRepo) Create(s *model.Session) error {
+	query := `
+        INSERT INTO sessions(id, user_id, refresh_hash, ip, user_agent, expires_at)
+        VALUES($1, $2, $3, $4, $5, $6)
+        RETURNING created_at, last_used_at
+    `
+	return r.db.QueryRow(query, s.ID, s.UserID, s.RefreshHash, s.IP, s.UserAgent, s.ExpiresAt).
+		Scan(&s.CreatedAt, &s.LastUsedAt)
+}
+
+func (r *sessionRepo) GetByID(id string) (*model.Session, error) {
+	query := `SELECT ` + sessionColumns + ` FROM sessions WHERE id = $1`
+	s, err := scanSession(r.db.QueryRow(query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSessionNotFound
+	}
+	return s, err
+}
+
+func (r *sessionRepo) Rotate(s *model.Session, oldHash, newHash string, expiresAt time.Time) error {
+	query := `
+        UPDATE sessions
+        SET previous_hash = refresh_hash, refresh_hash = $1, expires_at = $2, last_used_at = now()
+        WHERE id = $3 AND refresh_hash = $4 AND revoked_at IS NULL AND expires_at > now()
+        RETURNING last_used_at
+    `
+	err := r.db.QueryRow(query, newHash, expiresAt, s.ID, oldHash).Scan(&s.LastUsedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrSessionNotActive
+	}
+	if err != nil {
+		return err
+	}
+	s.PreviousHash = oldHash
+	s.RefreshHash = newHash
+	s.ExpiresAt = expiresAt
+	return nil
+}
+
+func (r *sessionRepo) Revoke(id string) error {
+	_, err := r.db.Exec(`UPDATE sessions SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL`, id)
+	return err
+}
+
+func (r *sessionRepo) RevokeAllByUser(userID int) (int64, error) {
+	res, err := r.db.Exec(`UPDATE sessions SET revoked_at = now() WHERE user_id = $1 AND revoked_at IS NULL`, userID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (r *sessionRepo) DeleteExpired(before time.Time) (int64, error) {
+	res, err := r.db.Exec(`DELETE FROM sessions WHERE expires_at < $1 OR revoked_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
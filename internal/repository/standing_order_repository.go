@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"Bank/internal/model"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrStandingOrderNotFound = errors.New("standing order not found")
+	// ErrStandingOrderClaimed — поручение уже взял в работу другой запуск шедулера.
+	ErrStandingOrderClaimed = errors.New("standing order is already being executed")
+	// ErrExecutionExists — платёж по поручению за эту плановую дату уже проведён.
+	ErrExecutionExists = errors.New("standing order execution already recorded")
+)
+
+type StandingOrderRepository interface {
+	Create(o *model.StandingOrder) error
+	GetByID(id int) (*model.StandingOrder, error)
+	ListByUser(userID int) ([]*model.StandingOrder, error)
+	ListDue(now time.Time) ([]*model.StandingOrder, error)
+	// Claim переносит next_run_at на until, если поручение активно и его
+	// next_run_at не менялся с момента чтения; иначе ErrStandingOrderClaimed.
+	Claim(o *model.StandingOrder, until time.Time) error
+	Update(o *model.StandingOrder) error
+	CreateExecution(e *model.StandingOrderExecution) error
+	// CreateExecutionTx записывает исполнение в транзакции перевода;
+	// повторный успех за ту же плановую дату — ErrExecutionExists.
+	CreateExecutionTx(tx *sql.Tx, e *model.StandingOrderExecution) error
+	ListExecutions(orderID int) ([]*model.StandingOrderExecution, error)
+}
+
+type standingOrderRepo struct {
+	db *sql.DB
+}
+
+func NewStandingOrderRepository(db *sql.DB) StandingOrderRepository {
+	return &standingOrderRepo{db: db}
+}
+
+const standingOrderColumns = `
+        id, user_id, from_account_id, to_account_id, amount, frequency, day_of_month,
+        end_date, max_executions, executions_count, scheduled_for, next_run_at,
+        attempts, status, created_at
+`
+
+func scanStandingOrder(row interface{ Scan(...interface{}) error }) (*model.StandingOrder, error) {
+	o := &model.StandingOrder{}
+	var day, maxExec sql.NullInt64
+	var endDate sql.NullTime
+	err := row.Scan(&o.ID, &o.UserID, &o.FromAccountID, &o.ToAccountID, &o.Amount, &o.Frequency, &day,
+		&endDate, &maxExec, &o.ExecutionsCount, &o.ScheduledFor, &o.NextRunAt,
+		&o.Attempts, &o.Status, &o.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if day.Valid {
+		d := int(day.Int64)
+		o.DayOfMonth = &d
+	}
+	if endDate.Valid {
+		o.EndDate = &endDate.Time
+	}
+	if maxExec.Valid {
+		m := int(maxExec.Int64)
+		o.MaxExecutions = &m
+	}
+	return o, nil
+}
+
+func (r *standingOrderRepo) Create(o *model.StandingOrder) error {
+	query := `
+        INSERT INTO standing_orders(user_id, from_account_id, to_account_id, amount, frequency,
+                                    day_of_month, end_date, max_executions, scheduled_for, next_run_at, status)
+        VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+        RETURNING id, created_at
+    `
+	return r.db.QueryRow(query,
+		o.UserID, o.FromAccountID, o.ToAccountID, o.Amount, o.Frequency,
+		o.DayOfMonth, o.EndDate, o.MaxExecutions, o.ScheduledFor, o.NextRunAt, o.Status,
+	).Scan(&o.ID, &o.CreatedAt)
+}
+
+func (r *standingOrderRepo) GetByID(id int) (*model.StandingOrder, error) {
+	query := `SELECT ` + standingOrderColumns + ` FROM standing_orders WHERE id = $1`
+	o, err := scanStandingOrder(r.db.QueryRow(query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrStandingOrderNotFound
+	}
+	return o, err
+}
+
+func (r *standingOrderRepo) ListByUser(userID int) ([]*model.StandingOrder, error) {
+	query := `SELECT ` + standingOrderColumns + ` FROM standing_orders WHERE user_id = $1 ORDER BY id`
+	return r.list(query, userID)
+}
+
+func (r *standingOrderRepo) ListDue(now time.Time) ([]*model.StandingOrder, error) {
+	query := `SELECT ` + standingOrderColumns + `
+        FROM standing_orders
+        WHERE status = 'active' AND next_run_at <= $1
+        ORDER BY next_run_at
+    `
+	return r.list(query, now)
+}
+
+func (r *standingOrderRepo) Claim(o *model.StandingOrder, until time.Time) error {
+	query := `
+        UPDATE standing_orders SET next_run_at = $1
+        WHERE id = $2 AND status = 'active' AND next_run_at = $3
+    `
+	res, err := r.db.Exec(query, until, o.ID, o.NextRunAt)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return ErrStandingOrderClaimed
+	}
+	o.NextRunAt = until
+	return nil
+}
+
+func (r *standingOrderRepo) list(query string, args ...interface{}) ([]*model.StandingOrder, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*model.StandingOrder
+	for rows.Next() {
+		o, err := scanStandingOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, o)
+	}
+	return list, rows.Err()
+}
+
+func (r *standingOrderRepo) Update(o *model.StandingOrder) error {
+	query := `
+        UPDATE standing_orders
+        SET executions_count = $1, scheduled_for = $2, next_run_at = $3, attempts = $4, status = $5
+        WHERE id = $6
+    `
+	res, err := r.db.Exec(query, o.ExecutionsCount, o.ScheduledFor, o.NextRunAt, o.Attempts, o.Status, o.ID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return ErrStandingOrderNotFound
+	}
+	return nil
+}
+
+func (r *standingOrderRepo) CreateExecution(e *model.StandingOrderExecution) error {
+	return createExecution(r.db, e)
+}
+
+func (r *standingOrderRepo) CreateExecutionTx(tx *sql.Tx, e *model.StandingOrderExecution) error {
+	return createExecution(tx, e)
+}
+
+func createExecution(q querier, e *model.StandingOrderExecution) error {
+	query := `
+        INSERT INTO standing_order_executions(order_id, attempt, scheduled_for, status, transaction_id, error)
+        VALUES($1, $2, $3, $4, $5, NULLIF($6, ''))
+        RETURNING id, executed_at
+    `
+	err := q.QueryRow(query, e.OrderID, e.Attempt, e.ScheduledFor, e.Status, e.TransactionID, e.Error).
+		Scan(&e.ID, &e.ExecutedAt)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrExecutionExists
+	}
+	return err
+}
+
+func (r *standingOrderRepo) ListExecutions(orderID int) ([]*model.StandingOrderExecution, error) {
+	query := `
+        SELECT id, order_id, attempt, scheduled_for, status, transaction_id, COALESCE(error, ''), executed_at
+        FROM standing_order_executions WHERE order_id = $1 ORDER BY executed_at DESC
+    `
+	rows, err := r.db.Query(query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*model.StandingOrderExecution
+	for rows.Next() {
+		e := &model.StandingOrderExecution{}
+		var txID sql.NullInt64
+		var scheduledFor sql.NullTime
+		if err := rows.Scan(&e.ID, &e.OrderID, &e.Attempt, &scheduledFor, &e.Status, &txID, &e.Error, &e.ExecutedAt); err != nil {
+			return nil, err
+		}
+		if scheduledFor.Valid {
+			e.ScheduledFor = &scheduledFor.Time
+		}
+		if txID.Valid {
+			id := int(txID.Int64)
+			e.TransactionID = &id
+		}
+		list = append(list, e)
+	}
+	return list, rows.Err()
+}
@@ -3,11 +3,24 @@ package repository
 import (
 	"Bank/internal/model"
 	"database/sql"
+	"errors"
 	"time"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrTransactionNotFound = errors.New("transaction not found")
+	ErrAlreadyReversed     = errors.New("transaction already reversed")
 )
 
 type TransactionRepository interface {
 	CreateTx(tx *sql.Tx, t *model.Transaction) error
+	GetByID(id int) (*model.Transaction, error)
+	// GetReversal возвращает проводку, отменяющую операцию id.
+	GetReversal(id int) (*model.Transaction, error)
+	// LinkCounterpartsTx связывает две ноги перевода друг с другом.
+	LinkCounterpartsTx(tx *sql.Tx, a, b *model.Transaction) error
 	ListByAccount(accountID int) ([]*model.Transaction, error)
 	ListByAccountBetween(accountID int, from, to time.Time) ([]*model.Transaction, error)
 }
@@ -20,45 +33,86 @@ func NewTransactionRepository(db *sql.DB) TransactionRepository {
 	return &transactionRepo{db: db}
 }
 
+const transactionColumns = `id, account_id, amount, type, direction, description, counterpart_id, reversal_of, created_at`
+
+func scanTransaction(row interface{ Scan(...interface{}) error }) (*model.Transaction, error) {
+	t := &model.Transaction{}
+	var counterpart, reversalOf sql.NullInt64
+	err := row.Scan(&t.ID, &t.AccountID, &t.Amount, &t.Type, &t.Direction, &t.Description, &counterpart, &reversalOf, &t.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	t.CounterpartID = nullIntPtr(counterpart)
+	t.ReversalOf = nullIntPtr(reversalOf)
+	return t, nil
+}
+
 func (r *transactionRepo) CreateTx(tx *sql.Tx, t *model.Transaction) error {
 	query := `
-        INSERT INTO transactions(account_id, amount, type, description)
-        VALUES($1, $2, $3, $4)
+        INSERT INTO transactions(account_id, amount, type, direction, description, counterpart_id, reversal_of)
+        VALUES($1, $2, $3, $4, $5, $6, $7)
         RETURNING id, created_at
     `
-	return tx.QueryRow(query, t.AccountID, t.Amount, t.Type, t.Description).
+	err := tx.QueryRow(query, t.AccountID, t.Amount, t.Type, t.Direction, t.Description, t.CounterpartID, t.ReversalOf).
 		Scan(&t.ID, &t.CreatedAt)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" && t.ReversalOf != nil {
+		return ErrAlreadyReversed
+	}
+	return err
 }
 
-func (r *transactionRepo) ListByAccount(accountID int) ([]*model.Transaction, error) {
+func (r *transactionRepo) GetByID(id int) (*model.Transaction, error) {
+	query := `SELECT ` + transactionColumns + ` FROM transactions WHERE id = $1`
+	t, err := scanTransaction(r.db.QueryRow(query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTransactionNotFound
+	}
+	return t, err
+}
+
+func (r *transactionRepo) GetReversal(id int) (*model.Transaction, error) {
+	query := `SELECT ` + transactionColumns + ` FROM transactions WHERE reversal_of = $1`
+	t, err := scanTransaction(r.db.QueryRow(query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTransactionNotFound
+	}
+	return t, err
+}
+
+func (r *transactionRepo) LinkCounterpartsTx(tx *sql.Tx, a, b *model.Transaction) error {
 	query := `
-        SELECT id, account_id, amount, type, description, created_at
-        FROM transactions WHERE account_id = $1 ORDER BY created_at DESC
+        UPDATE transactions
+        SET counterpart_id = CASE id WHEN $1 THEN $2 ELSE $1 END
+        WHERE id IN ($1, $2)
     `
-	rows, err := r.db.Query(query, accountID)
-	if err != nil {
-		return nil, err
+	if _, err := tx.Exec(query, a.ID, b.ID); err != nil {
+		return err
 	}
-	defer rows.Close()
+	a.CounterpartID = &b.ID
+	b.CounterpartID = &a.ID
+	return nil
+}
 
-	var list []*model.Transaction
-	for rows.Next() {
-		t := &model.Transaction{}
-		if err := rows.Scan(&t.ID, &t.AccountID, &t.Amount, &t.Type, &t.Description, &t.CreatedAt); err != nil {
-			return nil, err
-		}
-		list = append(list, t)
-	}
-	return list, rows.Err()
+func (r *transactionRepo) ListByAccount(accountID int) ([]*model.Transaction, error) {
+	query := `
+        SELECT ` + transactionColumns + `
+        FROM transactions WHERE account_id = $1 ORDER BY created_at DESC
+    `
+	return r.list(query, accountID)
 }
 
 func (r *transactionRepo) ListByAccountBetween(accountID int, from, to time.Time) ([]*model.Transaction, error) {
 	query := `
-        SELECT id, account_id, amount, type, description, created_at
+        SELECT ` + transactionColumns + `
         FROM transactions
         WHERE account_id = $1 AND created_at >= $2 AND created_at <= $3
     `
-	rows, err := r.db.Query(query, accountID, from, to)
+	return r.list(query, accountID, from, to)
+}
+
+func (r *transactionRepo) list(query string, args ...interface{}) ([]*model.Transaction, error) {
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -66,8 +120,8 @@ func (r *transactionRepo) ListByAccountBetween(accountID int, from, to time.Time
 
 	var out []*model.Transaction
 	for rows.Next() {
-		t := &model.Transaction{}
-		if err := rows.Scan(&t.ID, &t.AccountID, &t.Amount, &t.Type, &t.Description, &t.CreatedAt); err != nil {
+		t, err := scanTransaction(rows)
+		if err != nil {
 			return nil, err
 		}
 		out = append(out, t)
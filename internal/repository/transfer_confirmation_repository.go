@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"Bank/internal/model"
+	"database/sql"
+	"errors"
+)
+
+var ErrConfirmationNotFound = errors.New("transfer confirmation not found or expired")
+
+type TransferConfirmationRepository interface {
+	Create(c *model.TransferConfirmation) error
+	// Consume атомарно помечает подтверждение использованным и возвращает его.
+	// Истёкшие и уже использованные подтверждения дают ErrConfirmationNotFound.
+	Consume(userID int, token string) (*model.TransferConfirmation, error)
+}
+
+type transferConfirmationRepo struct {
+	db *sql.DB
+}
+
+func NewTransferConfirmationRepository(db *sql.DB) TransferConfirmationRepository {
+	return &transferConfirmationRepo{db: db}
+}
+
+func (r *transferConfirmationRepo) Create(c *model.TransferConfirmation) error {
+	query := `
+        INSERT INTO transfer_confirmations(token, user_id, from_account_id, to_account_id, amount, recipient_name, expires_at)
+        VALUES($1, $2, $3, $4, $5, $6, $7)
+        RETURNING created_at
+    `
+	return r.db.QueryRow(query,
+		c.Token, c.UserID, c.FromAccountID, c.ToAccountID, c.Amount, c.RecipientName, c.ExpiresAt,
+	).Scan(&c.CreatedAt)
+}
+
+func (r *transferConfirmationRepo) Consume(userID int, token string) (*model.TransferConfirmation, error) {
+	c := &model.TransferConfirmation{}
+	query := `
+        UPDATE transfer_confirmations
+        SET confirmed_at = now()
+        WHERE token = $1 AND user_id = $2 AND confirmed_at IS NULL AND expires_at > now()
+        RETURNING token, user_id, from_account_id, to_account_id, amount, recipient_name, expires_at, confirmed_at, created_at
+    `
+	err := r.db.QueryRow(query, token, userID).
+		Scan(&c.Token, &c.UserID, &c.FromAccountID, &c.ToAccountID, &c.Amount, &c.RecipientName, &c.ExpiresAt, &c.ConfirmedAt, &c.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrConfirmationNotFound
+	}
+	return c, err
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"Bank/internal/model"
+	"database/sql"
+	"errors"
+)
+
+var (
+	ErrTOTPNotFound = errors.New("two-factor authentication is not set up")
+	// ErrTOTPEnabled — второй фактор уже включён; заменить секрет можно только после отключения.
+	ErrTOTPEnabled = errors.New("two-factor authentication is already enabled")
+	// ErrOTPReplayed — код этого или более раннего шага уже использован.
+	ErrOTPReplayed = errors.New("one-time code already used")
+)
+
+type TwoFactorRepository interface {
+	GetTOTP(userID int) (*model.UserTOTP, error)
+	// SaveTOTP создаёт или заменяет ещё не подтверждённый секрет.
+	SaveTOTP(t *model.UserTOTP) error
+	Enable(userID int, counter int64) error
+	DeleteTOTP(userID int) error
+	// UseCounter запоминает шаг принятого кода, если он больше предыдущего.
+	UseCounter(userID int, counter int64) error
+	// ReplaceRecoveryCodes удаляет прежние коды и сохраняет хеши новых.
+	ReplaceRecoveryCodes(userID int, hashes []string) error
+	// UseRecoveryCode помечает неиспользованный код использованным.
+	UseRecoveryCode(userID int, hash string) (bool, error)
+	CountRecoveryCodes(userID int) (int, error)
+}
+
+type twoFactorRepo struct {
+	db *sql.DB
+}
+
+func NewTwoFactorRepository(db *sql.DB) TwoFactorRepository {
+	return &twoFactorRepo{db: db}
+}
+
+func (r *twoFactorRepo) GetTOTP(userID int) (*model.UserTOTP, error) {
+	t := &model.UserTOTP{}
+	var enabledAt sql.NullTime
+	query := `
+        SELECT user_id, secret_encrypted, key_id, enabled, last_counter, created_at, enabled_at
+        FROM user_totp WHERE user_id = $1
+    `
+	err := r.db.QueryRow(query, userID).Scan(&t.UserID, &t.SecretEncrypted, &t.KeyID, &t.Enabled,
+		&t.LastCounter, &t.CreatedAt, &enabledAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTOTPNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if enabledAt.Valid {
+		t.EnabledAt = &enabledAt.Time
+	}
+	return t, nil
+}
+
+func (r *twoFactorRepo) SaveTOTP(t *model.UserTOTP) error {
+	query := `
+        INSERT INTO user_totp(user_id, secret_encrypted, key_id)
+        VALUES($1, $2, $3)
+        ON CONFLICT (user_id) DO UPDATE
+        SET secret_encrypted = EXCLUDED.secret_encrypted, key_id = EXCLUDED.key_id,
+            last_counter = 0, created_at = now()
+        WHERE user_totp.enabled = FALSE
+        RETURNING created_at
+    `
+	err := r.db.QueryRow(query, t.UserID, t.SecretEncrypted, t.KeyID).Scan(&t.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrTOTPEnabled
+	}
+	return err
+}
+
+func (r *twoFactorRepo) Enable(userID int, counter int64) error {
+	query := `
+        UPDATE user_totp SET enabled = TRUE, enabled_at = now(), last_counter = $1
+        WHERE user_id = $2 AND enabled = FALSE
+    `
+	res, err := r.db.Exec(query, counter, userID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return ErrTOTPEnabled
+	}
+	return nil
+}
+
+func (r *twoFactorRepo) DeleteTOTP(userID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM user_totp WHERE user_id = $1`, userID); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *twoFactorRepo) UseCounter(userID int, counter int64) error {
+	res, err := r.db.Exec(`UPDATE user_totp SET last_counter = $1 WHERE user_id = $2 AND last_counter < $1`, counter, userID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return ErrOTPReplayed
+	}
+	return nil
+}
+
+func (r *twoFactorRepo) ReplaceRecoveryCodes(userID int, hashes []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, h := range hashes {
+		if _, err := tx.Exec(`INSERT INTO recovery_codes(user_id, code_hash) VALUES($1, $2)`, userID, h); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *twoFactorRepo) UseRecoveryCode(userID int, hash string) (bool, error) {
+	query := `
+        UPDATE recovery_codes SET used_at = now()
+        WHERE id = (SELECT id FROM recovery_codes
+                    WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL LIMIT 1)
+          AND used_at IS NULL
+    `
+	res, err := r.db.Exec(query, userID, hash)
+	if err != nil {
+		return false, err
+	}
+	rows, _ := res.RowsAffected()
+	return rows > 0, nil
+}
+
+func (r *twoFactorRepo) CountRecoveryCodes(userID int) (int, error) {
+	var n int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM recovery_codes WHERE user_id = $1 AND used_at IS NULL`, userID).Scan(&n)
+	return n, err
+}
@@ -16,6 +16,12 @@ type UserRepository interface {
 	GetByID(id int) (*model.User, error)
 	GetByEmail(email string) (*model.User, error)
 	GetByUsername(username string) (*model.User, error)
+	// MarkEmailVerified отмечает email подтверждённым, если это ещё не сделано.
+	MarkEmailVerified(id int) error
+	UpdatePassword(id int, passwordHash string) error
+	SetRole(id int, role string) error
+	// PromoteCustomer назначает роль, только если у пользователя роль клиента.
+	PromoteCustomer(id int, role string) (bool, error)
 }
 
 type userRepo struct {
@@ -31,41 +37,80 @@ func (r *userRepo) Create(u *model.User) error {
 	query := `
         INSERT INTO users(username, email, password_hash)
         VALUES($1, $2, $3)
-        RETURNING id, created_at
+        RETURNING id, role, created_at
     `
 	return r.db.QueryRow(query, u.Username, u.Email, u.PasswordHash).
-		Scan(&u.ID, &u.CreatedAt)
+		Scan(&u.ID, &u.Role, &u.CreatedAt)
 }
 
-func (r *userRepo) GetByID(id int) (*model.User, error) {
+const userColumns = `id, username, email, password_hash, email_verified_at, role, created_at`
+
+func scanUser(row interface{ Scan(...interface{}) error }) (*model.User, error) {
 	u := &model.User{}
-	query := `SELECT id, username, email, password_hash, created_at FROM users WHERE id = $1`
-	err := r.db.QueryRow(query, id).
-		Scan(&u.ID, &u.Username, &u.Email, &u.PasswordHash, &u.CreatedAt)
+	var verifiedAt sql.NullTime
+	err := row.Scan(&u.ID, &u.Username, &u.Email, &u.PasswordHash, &verifiedAt, &u.Role, &u.CreatedAt)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, ErrUserNotFound
 	}
-	return u, err
+	if err != nil {
+		return nil, err
+	}
+	if verifiedAt.Valid {
+		u.EmailVerifiedAt = &verifiedAt.Time
+	}
+	return u, nil
+}
+
+func (r *userRepo) GetByID(id int) (*model.User, error) {
+	return scanUser(r.db.QueryRow(`SELECT `+userColumns+` FROM users WHERE id = $1`, id))
 }
 
 func (r *userRepo) GetByEmail(email string) (*model.User, error) {
-	u := &model.User{}
-	query := `SELECT id, username, email, password_hash, created_at FROM users WHERE email = $1`
-	err := r.db.QueryRow(query, email).
-		Scan(&u.ID, &u.Username, &u.Email, &u.PasswordHash, &u.CreatedAt)
-	if errors.Is(err, sql.ErrNoRows) {
-		return nil, ErrUserNotFound
-	}
-	return u, err
+	return scanUser(r.db.QueryRow(`SELECT `+userColumns+` FROM users WHERE email = $1`, email))
 }
 
 func (r *userRepo) GetByUsername(username string) (*model.User, error) {
-	u := &model.User{}
-	query := `SELECT id, username, email, password_hash, created_at FROM users WHERE username = $1`
-	err := r.db.QueryRow(query, username).
-		Scan(&u.ID, &u.Username, &u.Email, &u.PasswordHash, &u.CreatedAt)
-	if errors.Is(err, sql.ErrNoRows) {
-		return nil, ErrUserNotFound
+	return scanUser(r.db.QueryRow(`SELECT `+userColumns+` FROM users WHERE username = $1`, username))
+}
+
+func (r *userRepo) MarkEmailVerified(id int) error {
+	res, err := r.db.Exec(`UPDATE users SET email_verified_at = COALESCE(email_verified_at, now()) WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *userRepo) UpdatePassword(id int, passwordHash string) error {
+	res, err := r.db.Exec(`UPDATE users SET password_hash = $1 WHERE id = $2`, passwordHash, id)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *userRepo) SetRole(id int, role string) error {
+	res, err := r.db.Exec(`UPDATE users SET role = $1 WHERE id = $2`, role, id)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *userRepo) PromoteCustomer(id int, role string) (bool, error) {
+	res, err := r.db.Exec(`UPDATE users SET role = $1 WHERE id = $2 AND role = 'customer'`, role, id)
+	if err != nil {
+		return false, err
 	}
-	return u, err
+	rows, _ := res.RowsAffected()
+	return rows > 0, nil
 }
@@ -13,6 +13,7 @@ var (
 	ErrAccessDenied        = errors.New("access denied")
 	ErrInsufficientFunds   = errors.New("insufficient funds")
 	ErrUnsupportedCurrency = errors.New("unsupported currency; only RUB allowed")
+	ErrSameAccount         = errors.New("source and destination accounts must differ")
 )
 
 type AccountService struct {
@@ -21,6 +22,9 @@ type AccountService struct {
 	accountRepo repository.AccountRepository
 	txRepo      repository.TransactionRepository
 	mailSvc     MailService
+	feeSvc      *FeeService
+	limitSvc    *LimitService
+	tfaSvc      *TwoFactorService
 }
 
 func NewAccountService(
@@ -29,6 +33,9 @@ func NewAccountService(
 	ar repository.AccountRepository,
 	tr repository.TransactionRepository,
 	mailSvc MailService,
+	feeSvc *FeeService,
+	limitSvc *LimitService,
+	tfaSvc *TwoFactorService,
 ) *AccountService {
 	return &AccountService{
 		db:          db,
@@ -36,6 +43,9 @@ func NewAccountService(
 		accountRepo: ar,
 		txRepo:      tr,
 		mailSvc:     mailSvc,
+		feeSvc:      feeSvc,
+		limitSvc:    limitSvc,
+		tfaSvc:      tfaSvc,
 	}
 }
 
@@ -83,6 +93,7 @@ func (s *AccountService) Deposit(userID, accountID int, amount float64) (*model.
 		AccountID:   accountID,
 		Amount:      amount,
 		Type:        "deposit",
+		Direction:   model.TxCredit,
 		Description: "Пополнение счёта",
 	}
 	if err := s.txRepo.CreateTx(tx, t); err != nil {
@@ -108,122 +119,235 @@ func (s *AccountService) Deposit(userID, accountID int, amount float64) (*model.
 	return t, nil
 }
 
-func (s *AccountService) Withdraw(userID, accountID int, amount float64) (*model.Transaction, error) {
+// Withdraw снимает деньги со счёта. Вторым значением возвращается
+// операция комиссии либо nil, если комиссия не взималась.
+func (s *AccountService) Withdraw(userID, accountID int, amount float64) (*model.Transaction, *model.Transaction, error) {
 	acc, err := s.accountRepo.GetByID(accountID)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if acc.UserID != userID {
-		return nil, ErrAccessDenied
+		return nil, nil, ErrAccessDenied
 	}
-	if acc.Balance < amount {
-		return nil, ErrInsufficientFunds
+	quote, err := s.feeSvc.Quote(userID, model.FeeOpWithdraw, amount)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	tx, err := s.db.Begin()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	// баланс и лимиты проверяются под блокировкой счёта: параллельные
+	// снятия выполняются по очереди и видят проводки друг друга
+	if acc, err = s.accountRepo.GetForUpdateTx(tx, accountID); err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+	if err := s.limitSvc.CheckTx(tx, userID, accountID, model.LimitOpWithdraw, amount); err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+	if err := s.feeSvc.ClaimTx(tx, userID, quote); err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+	if acc.AvailableBalance < amount+quote.Fee {
+		tx.Rollback()
+		return nil, nil, ErrInsufficientFunds
 	}
 
-	newBal := acc.Balance - amount
+	newBal := acc.Balance - amount - quote.Fee
 	if err = s.accountRepo.UpdateBalance(tx, accountID, newBal); err != nil {
 		tx.Rollback()
-		return nil, err
+		return nil, nil, err
 	}
 
 	t := &model.Transaction{
 		AccountID:   accountID,
 		Amount:      amount,
 		Type:        "withdraw",
+		Direction:   model.TxDebit,
 		Description: "Снятие со счёта",
 	}
 	if err = s.txRepo.CreateTx(tx, t); err != nil {
 		tx.Rollback()
-		return nil, err
+		return nil, nil, err
+	}
+	feeTx, err := s.feeSvc.Record(tx, accountID, quote)
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, err
 	}
 
 	if err := tx.Commit(); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	if user, e := s.userRepo.GetByID(userID); e == nil {
 		subject := "Со счёта сняты средства"
 		body := fmt.Sprintf(
 			"<h1>Снятие со счёта</h1>"+
-				"<p>Сумма: <strong>%.2f RUB</strong></p>"+
+				"<p>Сумма: <strong>%.2f RUB</strong></p>%s"+
 				"<p>Новый баланс: <strong>%.2f RUB</strong></p>",
-			amount, newBal,
+			amount, feeLine(quote.Fee), newBal,
 		)
 		_ = s.mailSvc.Send(user.Email, subject, body)
 	}
 
-	return t, nil
+	return t, feeTx, nil
+}
+
+// AuthorizeTransfer применяет политику второго фактора к переводу,
+// инициированному клиентом.
+func (s *AccountService) AuthorizeTransfer(userID int, amount float64, otp string) error {
+	return s.tfaSvc.RequireForAmount(userID, amount, otp)
+}
+
+// UserTransfer — перевод по запросу клиента с проверкой второго фактора.
+func (s *AccountService) UserTransfer(userID, fromID, toID int, amount float64, otp string) (*model.TransferResult, error) {
+	if err := s.AuthorizeTransfer(userID, amount, otp); err != nil {
+		return nil, err
+	}
+	return s.TransferWithNote(userID, fromID, toID, amount, "")
 }
 
-func (s *AccountService) Transfer(userID, fromID, toID int, amount float64) (*model.Transaction, *model.Transaction, error) {
+func (s *AccountService) Transfer(userID, fromID, toID int, amount float64) (*model.TransferResult, error) {
+	return s.TransferWithNote(userID, fromID, toID, amount, "")
+}
+
+// TransferWithNote выполняет перевод, дописывая note к описанию обеих операций.
+// Перевод другому клиенту тарифицируется как transfer_p2p.
+func (s *AccountService) TransferWithNote(userID, fromID, toID int, amount float64, note string) (*model.TransferResult, error) {
+	return s.transfer(userID, fromID, toID, amount, note, nil)
+}
+
+// TransferAndRecord выполняет перевод и в той же транзакции вызывает record,
+// например чтобы записать исполнение поручения. Ошибка record откатывает
+// перевод и возвращается как есть.
+func (s *AccountService) TransferAndRecord(
+	userID, fromID, toID int,
+	amount float64,
+	record func(tx *sql.Tx, res *model.TransferResult) error,
+) (*model.TransferResult, error) {
+	return s.transfer(userID, fromID, toID, amount, "", record)
+}
+
+func (s *AccountService) transfer(
+	userID, fromID, toID int,
+	amount float64,
+	note string,
+	record func(tx *sql.Tx, res *model.TransferResult) error,
+) (*model.TransferResult, error) {
+	// списание и зачисление одной строки затёрли бы друг друга
+	if fromID == toID {
+		return nil, ErrSameAccount
+	}
 	fromAcc, err := s.accountRepo.GetByID(fromID)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 	if fromAcc.UserID != userID {
-		return nil, nil, ErrAccessDenied
-	}
-	if fromAcc.Balance < amount {
-		return nil, nil, ErrInsufficientFunds
+		return nil, ErrAccessDenied
 	}
 
 	toAcc, err := s.accountRepo.GetByID(toID)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
+	}
+
+	quote := &model.FeeQuote{Operation: model.FeeOpTransfer, Amount: amount}
+	if toAcc.UserID != fromAcc.UserID {
+		if quote, err = s.feeSvc.Quote(userID, model.FeeOpTransfer, amount); err != nil {
+			return nil, err
+		}
 	}
 
 	tx, err := s.db.Begin()
 	if err != nil {
-		return nil, nil, err
+		return nil, err
+	}
+	if fromAcc, toAcc, err = s.lockPair(tx, fromID, toID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := s.limitSvc.CheckTx(tx, userID, fromID, model.LimitOpTransfer, amount); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := s.feeSvc.ClaimTx(tx, userID, quote); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if fromAcc.AvailableBalance < amount+quote.Fee {
+		tx.Rollback()
+		return nil, ErrInsufficientFunds
 	}
 
-	if err = s.accountRepo.UpdateBalance(tx, fromID, fromAcc.Balance-amount); err != nil {
+	fromBal := fromAcc.Balance - amount - quote.Fee
+	if err = s.accountRepo.UpdateBalance(tx, fromID, fromBal); err != nil {
 		tx.Rollback()
-		return nil, nil, err
+		return nil, err
 	}
 	if err = s.accountRepo.UpdateBalance(tx, toID, toAcc.Balance+amount); err != nil {
 		tx.Rollback()
-		return nil, nil, err
+		return nil, err
 	}
 
 	tFrom := &model.Transaction{
 		AccountID:   fromID,
 		Amount:      amount,
 		Type:        "transfer",
-		Description: "to:" + strconv.Itoa(toID),
+		Direction:   model.TxDebit,
+		Description: withNote("to:"+strconv.Itoa(toID), note),
 	}
 	if err = s.txRepo.CreateTx(tx, tFrom); err != nil {
 		tx.Rollback()
-		return nil, nil, err
+		return nil, err
 	}
 
 	tTo := &model.Transaction{
 		AccountID:   toID,
 		Amount:      amount,
 		Type:        "transfer",
-		Description: "from:" + strconv.Itoa(fromID),
+		Direction:   model.TxCredit,
+		Description: withNote("from:"+strconv.Itoa(fromID), note),
 	}
 	if err = s.txRepo.CreateTx(tx, tTo); err != nil {
 		tx.Rollback()
-		return nil, nil, err
+		return nil, err
+	}
+
+	if err = s.txRepo.LinkCounterpartsTx(tx, tFrom, tTo); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	res := &model.TransferResult{Debit: tFrom, Credit: tTo}
+	if toAcc.UserID != fromAcc.UserID {
+		if res.Fee, err = s.feeSvc.Record(tx, fromID, quote); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	if record != nil {
+		if err := record(tx, res); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
 	}
 
 	if err := tx.Commit(); err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	if user, e := s.userRepo.GetByID(userID); e == nil {
 		subject := "Перевод отправлен"
 		body := fmt.Sprintf(
 			"<h1>Перевод</h1>"+
-				"<p>Вы отправили <strong>%.2f RUB</strong> на счёт #%d</p>"+
+				"<p>Вы отправили <strong>%.2f RUB</strong> на счёт #%d</p>%s"+
 				"<p>Ваш новый баланс: <strong>%.2f RUB</strong></p>",
-			amount, toID, fromAcc.Balance-amount,
+			amount, toID, feeLine(quote.Fee), fromBal,
 		)
 		_ = s.mailSvc.Send(user.Email, subject, body)
 	}
@@ -238,5 +362,41 @@ func (s *AccountService) Transfer(userID, fromID, toID int, amount float64) (*mo
 		_ = s.mailSvc.Send(recipient.Email, subject, body)
 	}
 
-	return tFrom, tTo, nil
+	return res, nil
+}
+
+// lockPair блокирует оба счёта перевода в порядке возрастания id, чтобы
+// встречные переводы не взаимоблокировались, и возвращает их в порядке from, to.
+func (s *AccountService) lockPair(tx *sql.Tx, fromID, toID int) (*model.Account, *model.Account, error) {
+	first, second := fromID, toID
+	if first > second {
+		first, second = second, first
+	}
+	a, err := s.accountRepo.GetForUpdateTx(tx, first)
+	if err != nil {
+		return nil, nil, err
+	}
+	b, err := s.accountRepo.GetForUpdateTx(tx, second)
+	if err != nil {
+		return nil, nil, err
+	}
+	if a.ID == fromID {
+		return a, b, nil
+	}
+	return b, a, nil
+}
+
+// feeLine — строка письма о комиссии; пустая, если комиссии нет.
+func feeLine(fee float64) string {
+	if fee <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("<p>Комиссия: <strong>%.2f RUB</strong></p>", fee)
+}
+
+func withNote(description, note string) string {
+	if note == "" {
+		return description
+	}
+	return description + " " + note
 }
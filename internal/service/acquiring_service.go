@@ -0,0 +1,350 @@
+package service
+
+import (
+	"Bank/internal/model"
+	"Bank/internal/pan"
+	"Bank/internal/repository"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// cardHoldTTL — сколько живёт холд по карточной авторизации без клиринга.
+const cardHoldTTL = 7 * 24 * time.Hour
+
+// AcquiringService обрабатывает сообщения эквайера в упрощённом формате
+// ISO 8583: авторизация (0100) ставит холд на счёт карты, клиринг (0220)
+// списывает его, реверсал (0400) освобождает.
+type AcquiringService struct {
+	db       *sql.DB
+	cardSvc  *CardService
+	holdSvc  *HoldService
+	limitSvc *LimitService
+	txRepo   repository.CardTransactionRepository
+}
+
+func NewAcquiringService(
+	db *sql.DB,
+	cardSvc *CardService,
+	holdSvc *HoldService,
+	limitSvc *LimitService,
+	tr repository.CardTransactionRepository,
+) *AcquiringService {
+	return &AcquiringService{
+		db:       db,
+		cardSvc:  cardSvc,
+		holdSvc:  holdSvc,
+		limitSvc: limitSvc,
+		txRepo:   tr,
+	}
+}
+
+// Handle обрабатывает сообщение и возвращает ответ с кодом DE39.
+// Отказы — штатный ответ, ошибка возвращается только при сбое хранилища.
+func (s *AcquiringService) Handle(msg *model.AcquirerMessage) (*model.AcquirerResponse, error) {
+	switch msg.MTI {
+	case model.MTIAuthRequest:
+		return s.authorize(msg)
+	case model.MTIClearingAdvice:
+		return s.clear(msg)
+	case model.MTIReversalRequest:
+		return s.reverse(msg)
+	}
+	return &model.AcquirerResponse{MTI: msg.MTI, STAN: msg.STAN, RRN: msg.RRN, ResponseCode: model.RespInvalidTxn}, nil
+}
+
+func (s *AcquiringService) authorize(msg *model.AcquirerMessage) (*model.AcquirerResponse, error) {
+	resp := &model.AcquirerResponse{MTI: model.MTIAuthResponse, STAN: msg.STAN, RRN: msg.RRN}
+	a := &model.CardTransaction{
+		STAN:            msg.STAN,
+		RRN:             msg.RRN,
+		Amount:          msg.Amount,
+		MerchantID:      msg.MerchantID,
+		MerchantName:    msg.MerchantName,
+		MerchantCity:    msg.MerchantCity,
+		MerchantCountry: msg.MerchantCountry,
+		MCC:             msg.MCC,
+		Status:          model.CardTxDeclined,
+	}
+
+	card, code, err := s.checkCard(msg)
+	if err != nil {
+		return nil, err
+	}
+	if card != nil {
+		a.CardID = &card.ID
+	}
+	if code == model.RespApproved {
+		code, err = s.approve(card, msg, a)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resp.ResponseCode = code
+	if code == model.RespApproved {
+		resp.ApprovalCode = a.ApprovalCode
+		return resp, nil
+	}
+	a.ResponseCode = code
+	if err := s.txRepo.Create(a); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// approve в одной транзакции блокирует карту, проверяет её лимиты, ставит
+// холд и записывает одобренную операцию. Блокировка строки карты выстраивает
+// параллельные авторизации в очередь: каждая видит суммы предыдущих.
+// При отказе транзакция откатывается, и в журнал пишет вызывающий.
+func (s *AcquiringService) approve(card *model.Card, msg *model.AcquirerMessage, a *model.CardTransaction) (string, error) {
+	if msg.Amount <= 0 {
+		return model.RespInvalidTxn, nil
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return "", err
+	}
+	code, err := s.approveTx(tx, card, msg, a)
+	if err != nil || code != model.RespApproved {
+		tx.Rollback()
+		a.HoldID = nil
+		return code, err
+	}
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+func (s *AcquiringService) approveTx(tx *sql.Tx, card *model.Card, msg *model.AcquirerMessage, a *model.CardTransaction) (string, error) {
+	err := s.cardSvc.LockForAuthorization(tx, card)
+	if errors.Is(err, repository.ErrCardStatus) {
+		return model.RespRestrictedCard, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if code, err := s.checkControls(tx, card, msg); err != nil || code != model.RespApproved {
+		return code, err
+	}
+	if code, err := s.placeHold(tx, card, msg, a); err != nil || code != model.RespApproved {
+		return code, err
+	}
+
+	a.ResponseCode = model.RespApproved
+	a.Status = model.CardTxAuthorized
+	a.ApprovalCode = fmt.Sprintf("%06d", randInt(0, 999999))
+	if err := s.txRepo.CreateTx(tx, a); err != nil {
+		return "", err
+	}
+	return model.RespApproved, nil
+}
+
+// checkCard проверяет реквизиты и статус карты; при отказе возвращает
+// код ответа и, если карта найдена, саму карту для журнала.
+func (s *AcquiringService) checkCard(msg *model.AcquirerMessage) (*model.Card, string, error) {
+	if !pan.Valid(msg.PAN) {
+		return nil, model.RespInvalidCard, nil
+	}
+	card, err := s.cardSvc.CardByPAN(msg.PAN)
+	if errors.Is(err, repository.ErrCardNotFound) {
+		return nil, model.RespInvalidCard, nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	switch card.Status {
+	case model.CardActive:
+	case model.CardExpired:
+		return card, model.RespExpiredCard, nil
+	case model.CardBlockedPIN:
+		return card, model.RespPINTriesExceeded, nil
+	case model.CardUsed:
+		return card, model.RespRestrictedCard, nil
+	default:
+		return card, model.RespRestrictedCard, nil
+	}
+
+	exp, err := s.cardSvc.Expiry(card)
+	if err != nil {
+		return nil, "", err
+	}
+	if exp.Format("0601") != msg.Expiry {
+		return card, model.RespInvalidCard, nil
+	}
+	if !time.Now().Before(exp.AddDate(0, 1, 0)) ||
+		card.ValidUntil != nil && time.Now().After(*card.ValidUntil) {
+		return card, model.RespExpiredCard, nil
+	}
+	if !s.cardSvc.VerifyCVV(card, msg.CVV) {
+		return card, model.RespInvalidCVV, nil
+	}
+	if msg.PIN != "" {
+		switch err := s.cardSvc.VerifyPIN(card, msg.PIN); {
+		case errors.Is(err, ErrPINBlocked):
+			return card, model.RespPINTriesExceeded, nil
+		case errors.Is(err, ErrInvalidPIN), errors.Is(err, ErrPINNotSet):
+			return card, model.RespIncorrectPIN, nil
+		case err != nil:
+			return nil, "", err
+		}
+	}
+	return card, model.RespApproved, nil
+}
+
+// placeHold блокирует сумму на счёте карты и проверяет лимиты владельца.
+// Холд ставится первым: он блокирует строку счёта, как снятия и переводы,
+// и только затем лимиты блокируют строку пользователя — порядок блокировок
+// везде один. При превышении лимита холд откатывается вместе с транзакцией.
+func (s *AcquiringService) placeHold(tx *sql.Tx, card *model.Card, msg *model.AcquirerMessage, a *model.CardTransaction) (string, error) {
+	acc, err := s.cardSvc.Account(card)
+	if err != nil {
+		return "", err
+	}
+
+	description := fmt.Sprintf("card:%d %s", card.ID, msg.MerchantName)
+	hold, err := s.holdSvc.AuthorizeTx(tx, acc.ID, msg.Amount, description, cardHoldTTL)
+	if errors.Is(err, ErrInsufficientFunds) {
+		return model.RespInsufficientFunds, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := s.limitSvc.CheckTx(tx, acc.UserID, acc.ID, model.LimitOpPurchase, msg.Amount); err != nil {
+		if errors.Is(err, ErrLimitExceeded) {
+			return model.RespExceedsLimit, nil
+		}
+		return "", err
+	}
+	a.HoldID = &hold.ID
+	return model.RespApproved, nil
+}
+
+// checkControls применяет ограничения, заданные владельцем карты; суммы
+// за период считаются в транзакции, удерживающей блокировку карты.
+func (s *AcquiringService) checkControls(tx *sql.Tx, card *model.Card, msg *model.AcquirerMessage) (string, error) {
+	ctl, err := s.cardSvc.ControlsFor(card)
+	if err != nil {
+		return "", err
+	}
+
+	if msg.Channel == model.ChannelOnline && !ctl.OnlineEnabled ||
+		msg.Channel != model.ChannelOnline && !ctl.OfflineEnabled {
+		return model.RespNotPermitted, nil
+	}
+	if len(ctl.AllowedMCC) > 0 && !contains(ctl.AllowedMCC, msg.MCC) {
+		return model.RespNotPermitted, nil
+	}
+	if len(ctl.AllowedCountries) > 0 && !contains(ctl.AllowedCountries, msg.MerchantCountry) {
+		return model.RespNotPermitted, nil
+	}
+	if ctl.PerTransactionMax != nil && msg.Amount > *ctl.PerTransactionMax {
+		return model.RespExceedsLimit, nil
+	}
+
+	now := time.Now()
+	periods := []struct {
+		limit *float64
+		since time.Time
+	}{
+		{card.SpendCap, time.Time{}},
+		{ctl.DailyLimit, truncateDay(now)},
+		{ctl.MonthlyLimit, monthStart(now)},
+	}
+	for _, p := range periods {
+		if p.limit == nil {
+			continue
+		}
+		used, err := s.txRepo.SumByCardSinceTx(tx, card.ID, p.since)
+		if err != nil {
+			return "", err
+		}
+		if used+msg.Amount > *p.limit {
+			return model.RespExceedsLimit, nil
+		}
+	}
+	return model.RespApproved, nil
+}
+
+func contains(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
+// clear списывает холд авторизации; сумма клиринга может быть меньше
+// авторизованной (ноль — вся сумма).
+func (s *AcquiringService) clear(msg *model.AcquirerMessage) (*model.AcquirerResponse, error) {
+	resp := &model.AcquirerResponse{MTI: model.MTIClearingResponse, STAN: msg.STAN, RRN: msg.RRN, ApprovalCode: msg.ApprovalCode}
+	a, code, err := s.original(msg)
+	if err != nil || code != model.RespApproved {
+		resp.ResponseCode = code
+		return resp, err
+	}
+	if msg.Amount > a.Amount {
+		resp.ResponseCode = model.RespInvalidTxn
+		return resp, nil
+	}
+
+	hold, err := s.holdSvc.Capture(*a.HoldID, msg.Amount, model.TxCardPurchase)
+	if errors.Is(err, repository.ErrHoldNotOpen) {
+		resp.ResponseCode = model.RespOriginalNotFound
+		return resp, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.txRepo.Settle(a, hold.CapturedAmount, *hold.TransactionID); err != nil {
+		log.Printf("Карточная операция #%d списана, но не отмечена рассчитанной: %v", a.ID, err)
+	}
+	resp.ResponseCode = model.RespApproved
+	return resp, nil
+}
+
+func (s *AcquiringService) reverse(msg *model.AcquirerMessage) (*model.AcquirerResponse, error) {
+	resp := &model.AcquirerResponse{MTI: model.MTIReversalResponse, STAN: msg.STAN, RRN: msg.RRN, ApprovalCode: msg.ApprovalCode}
+	a, code, err := s.original(msg)
+	if err != nil || code != model.RespApproved {
+		resp.ResponseCode = code
+		return resp, err
+	}
+
+	if _, err := s.holdSvc.Release(*a.HoldID); err != nil && !errors.Is(err, repository.ErrHoldNotOpen) {
+		return nil, err
+	}
+	if err := s.txRepo.Reverse(a); err != nil &&
+		!errors.Is(err, repository.ErrCardTransactionClosed) {
+		return nil, err
+	}
+	resp.ResponseCode = model.RespApproved
+	return resp, nil
+}
+
+// original находит одобренную операцию, к которой относится клиринг или реверсал.
+func (s *AcquiringService) original(msg *model.AcquirerMessage) (*model.CardTransaction, string, error) {
+	card, err := s.cardSvc.CardByPAN(msg.PAN)
+	if errors.Is(err, repository.ErrCardNotFound) {
+		return nil, model.RespInvalidCard, nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	a, err := s.txRepo.GetAuthorized(card.ID, msg.ApprovalCode)
+	if errors.Is(err, repository.ErrCardTransactionNotFound) {
+		return nil, model.RespOriginalNotFound, nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	if a.HoldID == nil {
+		return nil, model.RespOriginalNotFound, nil
+	}
+	return a, model.RespApproved, nil
+}
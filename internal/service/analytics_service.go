@@ -38,7 +38,7 @@ func (s *AnalyticsService) GetMonthlyStats(userID int) (*model.MonthlyStats, err
 			return nil, err
 		}
 		for _, t := range txs {
-			if t.Type == "deposit" || t.Type == "transfer_in" {
+			if t.Type == "deposit" || t.Type == "transfer_in" || t.Type == model.TxReversalIn {
 				totalIncome += t.Amount
 			} else {
 				totalExpense += t.Amount
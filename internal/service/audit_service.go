@@ -0,0 +1,48 @@
+package service
+
+import (
+	"Bank/internal/model"
+	"Bank/internal/repository"
+	"errors"
+	"log"
+	"time"
+)
+
+var ErrTooManyAttempts = errors.New("too many attempts, try again later")
+
+// AuditService ведёт журнал чувствительных действий. Ошибка записи в журнал
+// не прерывает операцию, а только логируется.
+type AuditService struct {
+	auditRepo repository.AuditRepository
+}
+
+func NewAuditService(ar repository.AuditRepository) *AuditService {
+	return &AuditService{auditRepo: ar}
+}
+
+func (s *AuditService) Record(userID int, action string, targetID int, success bool, ip, details string) {
+	e := &model.AuditEntry{
+		UserID:   &userID,
+		Action:   action,
+		TargetID: &targetID,
+		Success:  success,
+		IP:       ip,
+		Details:  details,
+	}
+	if err := s.auditRepo.Create(e); err != nil {
+		log.Printf("Не удалось записать действие %s пользователя #%d в журнал: %v", action, userID, err)
+	}
+}
+
+// CheckRate возвращает ErrTooManyAttempts, если за окно window пользователь
+// уже совершил max действий action.
+func (s *AuditService) CheckRate(userID int, action string, max int, window time.Duration) error {
+	n, err := s.auditRepo.CountSince(userID, action, time.Now().Add(-window))
+	if err != nil {
+		return err
+	}
+	if n >= max {
+		return ErrTooManyAttempts
+	}
+	return nil
+}
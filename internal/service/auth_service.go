@@ -3,8 +3,16 @@ package service
 import (
 	"Bank/internal/model"
 	"Bank/internal/repository"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"log"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -13,20 +21,62 @@ import (
 
 var (
 	ErrInvalidCredentials = errors.New("invalid email or password")
+	ErrStepUpFailed       = errors.New("step-up authentication failed")
+	ErrInvalidRefresh     = errors.New("invalid or expired refresh token")
+	// ErrInvalidVerification — ссылка подтверждения повреждена, истекла или выдана для другого адреса.
+	ErrInvalidVerification  = errors.New("invalid or expired verification token")
+	ErrEmailAlreadyVerified = errors.New("email address is already verified")
+)
+
+// Время жизни токенов: access-токен короткий, отзыв сессии проверяется при
+// каждом запросе; refresh-токен продлевается при каждом обновлении.
+const (
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 30 * 24 * time.Hour
+	// sessionRetention — сколько хранить истёкшие и отозванные сессии.
+	sessionRetention = 30 * 24 * time.Hour
+
+	emailVerificationTTL = 24 * time.Hour
+	passwordResetTTL     = time.Hour
 )
 
 type AuthService struct {
-	userRepo  repository.UserRepository
-	jwtSecret string
+	userRepo    repository.UserRepository
+	sessionRepo repository.SessionRepository
+	resetRepo   repository.PasswordResetRepository
+	tfaSvc      *TwoFactorService
+	throttle    *LoginThrottleService
+	mailSvc     MailService
+	jwtSecret   string
+	signKey     []byte
+	baseURL     string
 }
 
-func NewAuthService(u repository.UserRepository, jwtSecret string) *AuthService {
+func NewAuthService(
+	u repository.UserRepository,
+	sr repository.SessionRepository,
+	prr repository.PasswordResetRepository,
+	tfaSvc *TwoFactorService,
+	throttle *LoginThrottleService,
+	mailSvc MailService,
+	jwtSecret, signKey, baseURL string,
+) *AuthService {
 	return &AuthService{
-		userRepo:  u,
-		jwtSecret: jwtSecret,
+		userRepo:    u,
+		sessionRepo: sr,
+		resetRepo:   prr,
+		tfaSvc:      tfaSvc,
+		throttle:    throttle,
+		mailSvc:     mailSvc,
+		jwtSecret:   jwtSecret,
+		signKey:     []byte(signKey),
+		baseURL:     strings.TrimRight(baseURL, "/"),
 	}
 }
 
+// Register создаёт пользователя с неподтверждённым email и отправляет письмо
+// со ссылкой подтверждения. До подтверждения доступны только вход, выход
+// и повторная отправка письма.
 func (s *AuthService) Register(reg *model.UserRegistration) (*model.User, error) {
 	if _, err := s.userRepo.GetByEmail(reg.Email); err == nil {
 		return nil, repository.ErrUserExists
@@ -49,26 +99,390 @@ func (s *AuthService) Register(reg *model.UserRegistration) (*model.User, error)
 	if err := s.userRepo.Create(user); err != nil {
 		return nil, err
 	}
+	if err := s.sendVerification(user); err != nil {
+		// пользователь уже создан: письмо можно запросить повторно
+		log.Printf("Письмо подтверждения для пользователя #%d: %v", user.ID, err)
+	}
 	return user, nil
 }
 
-func (s *AuthService) Login(login *model.UserLogin) (string, error) {
+// ResendVerification повторно отправляет письмо подтверждения.
+func (s *AuthService) ResendVerification(userID int) error {
+	u, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if u.EmailVerifiedAt != nil {
+		return ErrEmailAlreadyVerified
+	}
+	return s.sendVerification(u)
+}
+
+// VerifyEmail подтверждает email по токену из письма. Повторный переход
+// по действующей ссылке не считается ошибкой.
+func (s *AuthService) VerifyEmail(token string) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return ErrInvalidVerification
+	}
+	userID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return ErrInvalidVerification
+	}
+	exp, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || time.Now().Unix() > exp {
+		return ErrInvalidVerification
+	}
+	u, err := s.userRepo.GetByID(userID)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return ErrInvalidVerification
+	}
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(s.signVerification(parts[0]+"."+parts[1], u.Email))) {
+		return ErrInvalidVerification
+	}
+	return s.userRepo.MarkEmailVerified(userID)
+}
+
+// EmailVerified сообщает, подтверждён ли email; вызывается middleware.
+func (s *AuthService) EmailVerified(userID int) (bool, error) {
+	u, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return false, err
+	}
+	return u.EmailVerifiedAt != nil, nil
+}
+
+// ForgotPassword отправляет ссылку сброса пароля. Для неизвестного адреса
+// ничего не происходит, чтобы по ответу нельзя было узнать, есть ли клиент.
+func (s *AuthService) ForgotPassword(email string) error {
+	u, err := s.userRepo.GetByEmail(email)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	token, err := randomToken(32)
+	if err != nil {
+		return err
+	}
+	// действует только последняя выданная ссылка
+	if err := s.resetRepo.InvalidateByUser(u.ID); err != nil {
+		return err
+	}
+	reset := &model.PasswordReset{
+		UserID:    u.ID,
+		TokenHash: hashSecret(token),
+		ExpiresAt: time.Now().Add(passwordResetTTL),
+	}
+	if err := s.resetRepo.Create(reset); err != nil {
+		return err
+	}
+
+	link := s.baseURL + "/password/reset?token=" + token
+	body := fmt.Sprintf(
+		"<h1>Сброс пароля</h1>"+
+			"<p>Чтобы задать новый пароль, перейдите по ссылке: <a href=\"%s\">%s</a></p>"+
+			"<p>Ссылка действительна 1 час. Если вы не запрашивали сброс, просто проигнорируйте письмо.</p>",
+		link, link,
+	)
+	if err := s.mailSvc.Send(u.Email, "Сброс пароля", body); err != nil {
+		log.Printf("Письмо сброса пароля для пользователя #%d: %v", u.ID, err)
+	}
+	return nil
+}
+
+// ResetPassword задаёт новый пароль по одноразовому токену и завершает
+// все сессии пользователя. Получение письма подтверждает и сам email.
+func (s *AuthService) ResetPassword(req *model.ResetPassword) error {
+	reset, err := s.resetRepo.Consume(hashSecret(req.Token))
+	if err != nil {
+		return err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.UpdatePassword(reset.UserID, string(hash)); err != nil {
+		return err
+	}
+	if err := s.resetRepo.InvalidateByUser(reset.UserID); err != nil {
+		return err
+	}
+	if err := s.userRepo.MarkEmailVerified(reset.UserID); err != nil {
+		return err
+	}
+	n, err := s.sessionRepo.RevokeAllByUser(reset.UserID)
+	if err != nil {
+		return err
+	}
+	log.Printf("Пароль пользователя #%d сброшен, отозвано сессий: %d", reset.UserID, n)
+
+	u, err := s.userRepo.GetByID(reset.UserID)
+	if err != nil {
+		return err
+	}
+	// новый пароль снимает и блокировку входа после неудачных попыток
+	if err := s.throttle.Succeeded(u.Email); err != nil {
+		return err
+	}
+	_ = s.mailSvc.Send(u.Email, "Пароль изменён",
+		"<h1>Пароль изменён</h1><p>Пароль от вашего аккаунта был сброшен, все сеансы завершены. "+
+			"Если это были не вы, срочно обратитесь в банк.</p>")
+	return nil
+}
+
+func (s *AuthService) sendVerification(u *model.User) error {
+	payload := fmt.Sprintf("%d.%d", u.ID, time.Now().Add(emailVerificationTTL).Unix())
+	link := s.baseURL + "/email/verify?token=" + payload + "." + s.signVerification(payload, u.Email)
+	body := fmt.Sprintf(
+		"<h1>Подтверждение email</h1><p>Здравствуйте, %s!</p>"+
+			"<p>Чтобы подтвердить адрес, перейдите по ссылке: <a href=\"%s\">%s</a></p>"+
+			"<p>Ссылка действительна 24 часа.</p>",
+		u.Username, link, link,
+	)
+	return s.mailSvc.Send(u.Email, "Подтвердите email", body)
+}
+
+// signVerification подписывает "<id>.<expires_unix>" вместе с адресом,
+// поэтому ссылка перестаёт действовать при смене email.
+func (s *AuthService) signVerification(payload, email string) string {
+	h := hmac.New(sha256.New, s.signKey)
+	h.Write([]byte("email-verify:" + payload + ":" + email))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+// Login проверяет пароль и, если включён второй фактор, код OTP,
+// после чего открывает новую сессию. Неверный пароль или код учитывается
+// в счётчиках неудачных входов по email и IP.
+func (s *AuthService) Login(login *model.UserLogin, ip, userAgent string) (*model.TokenPair, error) {
+	if err := s.throttle.Check(login.Email, ip); err != nil {
+		return nil, err
+	}
 	u, err := s.userRepo.GetByEmail(login.Email)
 	if err != nil {
-		return "", ErrInvalidCredentials
+		return nil, s.loginFailed(login.Email, ip, nil, ErrInvalidCredentials)
 	}
 	if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(login.Password)); err != nil {
-		return "", ErrInvalidCredentials
+		return nil, s.loginFailed(login.Email, ip, u, ErrInvalidCredentials)
 	}
-	return s.generateToken(u.ID)
+	if err := s.tfaSvc.Check(u.ID, login.OTP); err != nil {
+		if errors.Is(err, ErrInvalidOTP) {
+			return nil, s.loginFailed(login.Email, ip, u, err)
+		}
+		return nil, err
+	}
+	if err := s.throttle.Succeeded(login.Email); err != nil {
+		return nil, err
+	}
+	return s.openSession(u, ip, userAgent)
 }
 
-func (s *AuthService) generateToken(userID int) (string, error) {
-	claims := jwt.RegisteredClaims{
-		Subject:   strconv.Itoa(userID),
-		ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-		IssuedAt:  jwt.NewNumericDate(time.Now()),
+// loginFailed учитывает неудачную попытку и возвращает исходную ошибку входа.
+func (s *AuthService) loginFailed(email, ip string, u *model.User, loginErr error) error {
+	if err := s.throttle.Failed(email, ip, u); err != nil {
+		return err
+	}
+	return loginErr
+}
+
+// UnlockLogin снимает блокировку входа по ссылке из письма.
+func (s *AuthService) UnlockLogin(token string) error {
+	return s.throttle.Unlock(token)
+}
+
+func (s *AuthService) openSession(u *model.User, ip, userAgent string) (*model.TokenPair, error) {
+	secret, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	id, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+	sess := &model.Session{
+		ID:          id,
+		UserID:      u.ID,
+		RefreshHash: hashSecret(secret),
+		IP:          ip,
+		UserAgent:   userAgent,
+		ExpiresAt:   time.Now().Add(refreshTokenTTL),
+	}
+	if err := s.sessionRepo.Create(sess); err != nil {
+		return nil, err
+	}
+	return s.tokenPair(sess, u.Role, secret)
+}
+
+// Refresh выдаёт новую пару токенов по refresh-токену вида "<сессия>.<секрет>".
+// Каждый refresh-токен одноразовый: предъявление уже заменённого токена
+// означает, что он утёк, и сессия отзывается целиком.
+func (s *AuthService) Refresh(refreshToken string) (*model.TokenPair, error) {
+	id, secret, ok := strings.Cut(refreshToken, ".")
+	if !ok {
+		return nil, ErrInvalidRefresh
+	}
+	sess, err := s.sessionRepo.GetByID(id)
+	if errors.Is(err, repository.ErrSessionNotFound) {
+		return nil, ErrInvalidRefresh
+	}
+	if err != nil {
+		return nil, err
+	}
+	if sess.RevokedAt != nil || time.Now().After(sess.ExpiresAt) {
+		return nil, ErrInvalidRefresh
+	}
+
+	hash := hashSecret(secret)
+	if sess.PreviousHash != "" && subtle.ConstantTimeCompare([]byte(hash), []byte(sess.PreviousHash)) == 1 {
+		log.Printf("Повторное использование refresh-токена, сессия %s пользователя #%d отозвана", sess.ID, sess.UserID)
+		if err := s.sessionRepo.Revoke(sess.ID); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidRefresh
+	}
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(sess.RefreshHash)) != 1 {
+		return nil, ErrInvalidRefresh
+	}
+
+	u, err := s.userRepo.GetByID(sess.UserID)
+	if err != nil {
+		return nil, err
+	}
+	next, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	err = s.sessionRepo.Rotate(sess, hash, hashSecret(next), time.Now().Add(refreshTokenTTL))
+	if errors.Is(err, repository.ErrSessionNotActive) {
+		return nil, ErrInvalidRefresh
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.tokenPair(sess, u.Role, next)
+}
+
+// Logout отзывает сессию; access-токены сессии перестают приниматься сразу.
+func (s *AuthService) Logout(sessionID string) error {
+	return s.sessionRepo.Revoke(sessionID)
+}
+
+// LogoutAll отзывает все сессии пользователя на всех устройствах.
+func (s *AuthService) LogoutAll(userID int) (int64, error) {
+	return s.sessionRepo.RevokeAllByUser(userID)
+}
+
+// SessionActive сообщает, что сессия пользователя не отозвана и не истекла;
+// вызывается middleware при каждом запросе.
+func (s *AuthService) SessionActive(userID int, sessionID string) (bool, error) {
+	sess, err := s.sessionRepo.GetByID(sessionID)
+	if errors.Is(err, repository.ErrSessionNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return sess.UserID == userID && sess.RevokedAt == nil && time.Now().Before(sess.ExpiresAt), nil
+}
+
+// PurgeSessions удаляет давно истёкшие и отозванные сессии; вызывается шедулером.
+func (s *AuthService) PurgeSessions() error {
+	n, err := s.sessionRepo.DeleteExpired(time.Now().Add(-sessionRetention))
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		log.Printf("Удалено старых сессий: %d", n)
+	}
+	return nil
+}
+
+// VerifyStepUp повторно проверяет пароль пользователя, а при включённом
+// втором факторе и код OTP, перед чувствительной операцией.
+func (s *AuthService) VerifyStepUp(userID int, req *model.StepUp) error {
+	u, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	// пароль подбирается тем же счётчиком, что и код второго фактора
+	if err := s.throttle.CheckVerification(userID); err != nil {
+		return err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(req.Password)); err != nil {
+		if ferr := s.throttle.VerificationFailed(userID); ferr != nil {
+			return ferr
+		}
+		return ErrStepUpFailed
+	}
+	err = s.tfaSvc.Check(userID, req.OTP)
+	if errors.Is(err, ErrOTPRequired) || errors.Is(err, ErrInvalidOTP) {
+		return fmt.Errorf("%w: %w", ErrStepUpFailed, err)
+	}
+	return err
+}
+
+// SetupTwoFactor выдаёт новый секрет TOTP после повторной аутентификации.
+func (s *AuthService) SetupTwoFactor(userID int, req *model.StepUp) (*model.TOTPSetup, error) {
+	if err := s.VerifyStepUp(userID, req); err != nil {
+		return nil, err
+	}
+	return s.tfaSvc.Setup(userID)
+}
+
+// DisableTwoFactor отключает второй фактор; требует пароль и действующий код.
+func (s *AuthService) DisableTwoFactor(userID int, req *model.StepUp) error {
+	if err := s.VerifyStepUp(userID, req); err != nil {
+		return err
+	}
+	return s.tfaSvc.Disable(userID)
+}
+
+// RegenerateRecoveryCodes заменяет коды восстановления после повторной аутентификации.
+func (s *AuthService) RegenerateRecoveryCodes(userID int, req *model.StepUp) (*model.RecoveryCodes, error) {
+	if err := s.VerifyStepUp(userID, req); err != nil {
+		return nil, err
+	}
+	return s.tfaSvc.RegenerateRecoveryCodes(userID)
+}
+
+func (s *AuthService) tokenPair(sess *model.Session, role, secret string) (*model.TokenPair, error) {
+	access, err := s.generateToken(sess.UserID, role, sess.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &model.TokenPair{
+		AccessToken:  access,
+		RefreshToken: sess.ID + "." + secret,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(accessTokenTTL.Seconds()),
+	}, nil
+}
+
+// generateToken выпускает access-токен; jti — идентификатор сессии.
+func (s *AuthService) generateToken(userID int, role, sessionID string) (string, error) {
+	claims := model.AccessClaims{
+		Role: role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.Itoa(userID),
+			ID:        sessionID,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(accessTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(s.jwtSecret))
 }
+
+// hashSecret — SHA-256 секрета refresh-токена; в БД хранится только он.
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -2,52 +2,98 @@ package service
 
 import (
 	"Bank/internal/model"
+	"Bank/internal/pan"
 	"Bank/internal/repository"
-	"bytes"
 	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
+	"database/sql"
 	"encoding/hex"
 	"errors"
 	"fmt"
-	"io"
+	"log"
 	"math/big"
-	"os"
-	"strconv"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
-	"golang.org/x/crypto/openpgp"
-	"golang.org/x/crypto/openpgp/armor"
 )
 
-var ErrCardNotYours = errors.New("account does not belong to user")
+var (
+	ErrCardNotYours = errors.New("account does not belong to user")
+	ErrPINNotSet    = errors.New("card pin is not set")
+	ErrInvalidPIN   = errors.New("invalid pin")
+	ErrPINBlocked   = errors.New("card is blocked after too many wrong pin attempts")
+	ErrWeakPIN      = errors.New("pin is too simple")
+	// ErrNotReissuable — виртуальные карты не перевыпускаются, выпускается новая.
+	ErrNotReissuable  = errors.New("virtual cards cannot be reissued")
+	ErrUnknownProduct = errors.New("unknown card product")
+)
+
+// maxNumberAttempts — сколько раз пытаться сгенерировать свободный номер.
+const maxNumberAttempts = 10
+
+// reencryptBatch — сколько карт перешифровывается за один запрос к БД.
+const reencryptBatch = 100
+
+// maxPINAttempts — после стольких неверных PIN подряд карта блокируется.
+const maxPINAttempts = 3
+
+// cardExpiryLayout — формат срока действия карты (MM/YYYY).
+const cardExpiryLayout = "01/2006"
+
+// Срок действия виртуальных карт по умолчанию, в днях.
+const (
+	defaultVirtualDays   = 365
+	defaultSingleUseDays = 1
+)
+
+// Ограничение на показ реквизитов карты.
+const (
+	revealMaxAttempts = 5
+	revealWindow      = time.Hour
+)
 
 type CardService struct {
-	pubKeyPath        string
-	privKeyPath       string
-	privKeyPassphrase string
-	hmacSecret        []byte
-	cardRepo          repository.CardRepository
-	acctRepo          repository.AccountRepository
+	db         *sql.DB
+	keys       *KeyManager
+	hmacSecret []byte
+	cardRepo   repository.CardRepository
+	cardTxRepo repository.CardTransactionRepository
+	acctRepo   repository.AccountRepository
+	binRanges  []pan.Range
+	feeSvc     *FeeService
+	authSvc    *AuthService
+	auditSvc   *AuditService
 }
 
 func NewCardService(
-	pubKeyPath, privKeyPath, privKeyPassphrase, hmacSecret string,
+	db *sql.DB,
+	keys *KeyManager,
+	hmacSecret string,
+	binRanges []pan.Range,
 	cr repository.CardRepository,
+	ctr repository.CardTransactionRepository,
 	ar repository.AccountRepository,
+	feeSvc *FeeService,
+	authSvc *AuthService,
+	auditSvc *AuditService,
 ) *CardService {
 	return &CardService{
-		pubKeyPath:        pubKeyPath,
-		privKeyPath:       privKeyPath,
-		privKeyPassphrase: privKeyPassphrase,
-		hmacSecret:        []byte(hmacSecret),
-		cardRepo:          cr,
-		acctRepo:          ar,
+		db:         db,
+		keys:       keys,
+		hmacSecret: []byte(hmacSecret),
+		binRanges:  binRanges,
+		cardRepo:   cr,
+		cardTxRepo: ctr,
+		acctRepo:   ar,
+		feeSvc:     feeSvc,
+		authSvc:    authSvc,
+		auditSvc:   auditSvc,
 	}
 }
 
-func (s *CardService) GenerateCard(userID, accountID int) (*model.Card, error) {
+// GenerateCard выпускает физическую карту продукта product (пустой — продукт по умолчанию).
+func (s *CardService) GenerateCard(userID, accountID int, product string) (*model.Card, error) {
 	acc, err := s.acctRepo.GetByID(accountID)
 	if err != nil {
 		return nil, err
@@ -55,45 +101,487 @@ func (s *CardService) GenerateCard(userID, accountID int) (*model.Card, error) {
 	if acc.UserID != userID {
 		return nil, ErrCardNotYours
 	}
+	quote, err := s.feeSvc.Quote(userID, model.FeeOpCardIssue, 0)
+	if err != nil {
+		return nil, err
+	}
 
-	number := generateLuhnNumber(16)
+	card, _, err := s.newCard(accountID, product, time.Now().AddDate(3, 0, 0))
+	if err != nil {
+		return nil, err
+	}
 
-	expiry := time.Now().AddDate(3, 0, 0).Format("01/2006") // MM/YYYY
+	// комиссия за выпуск списывается в одной транзакции с созданием карты
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	if acc, err = s.acctRepo.GetForUpdateTx(tx, accountID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := s.feeSvc.ClaimTx(tx, userID, quote); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if acc.AvailableBalance < quote.Fee {
+		tx.Rollback()
+		return nil, ErrInsufficientFunds
+	}
+	if quote.Fee > 0 {
+		if err := s.acctRepo.UpdateBalance(tx, accountID, acc.Balance-quote.Fee); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	if _, err := s.feeSvc.Record(tx, accountID, quote); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := s.cardRepo.CreateTx(tx, card); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return card, nil
+}
 
-	cvv := fmt.Sprintf("%03d", randInt(0, 999))
+// IssueVirtual мгновенно выпускает виртуальную или одноразовую карту.
+// Полные реквизиты и CVV возвращаются только в ответе на выпуск.
+func (s *CardService) IssueVirtual(userID int, req *model.VirtualCardCreate) (*model.VirtualCardIssued, error) {
+	acc, err := s.acctRepo.GetByID(req.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if acc.UserID != userID {
+		return nil, ErrCardNotYours
+	}
 
-	numEnc, err := encryptWithPGP(s.pubKeyPath, []byte(number))
+	kind, days := model.CardKindVirtual, defaultVirtualDays
+	if req.SingleUse {
+		kind, days = model.CardKindSingleUse, defaultSingleUseDays
+	}
+	if req.ValidDays > 0 {
+		days = req.ValidDays
+	}
+	validUntil := time.Now().AddDate(0, 0, days)
+
+	card, cvv, err := s.newCard(acc.ID, req.Product, validUntil)
 	if err != nil {
 		return nil, err
 	}
-	expEnc, err := encryptWithPGP(s.pubKeyPath, []byte(expiry))
+	card.Kind = kind
+	card.SpendCap = req.SpendCap
+	card.ValidUntil = &validUntil
+
+	tx, err := s.db.Begin()
 	if err != nil {
 		return nil, err
 	}
+	if err := s.cardRepo.CreateTx(tx, card); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
 
-	cvvHash, err := bcrypt.GenerateFromPassword([]byte(cvv), bcrypt.DefaultCost)
+	numPlain, err := s.keys.Decrypt(card.KeyID, card.NumberEncrypted)
 	if err != nil {
 		return nil, err
 	}
+	return &model.VirtualCardIssued{
+		ID:         card.ID,
+		Kind:       card.Kind,
+		Number:     string(numPlain),
+		Expiry:     validUntil.Format(cardExpiryLayout),
+		CVV:        cvv,
+		SpendCap:   card.SpendCap,
+		ValidUntil: validUntil,
+	}, nil
+}
 
-	h := hmac.New(sha256.New, s.hmacSecret)
-	h.Write([]byte(number))
-	mac := hex.EncodeToString(h.Sum(nil))
+// newCard генерирует номер из диапазона BIN продукта, срок действия (месяц
+// expiresAt) и CVV новой карты счёта; CVV возвращается открытым, чтобы его
+// можно было показать один раз.
+func (s *CardService) newCard(accountID int, product string, expiresAt time.Time) (*model.Card, string, error) {
+	r, err := s.binRange(product)
+	if err != nil {
+		return nil, "", err
+	}
+	number, err := s.uniqueNumber(r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	expiry := expiresAt.Format(cardExpiryLayout)
+
+	cvv := fmt.Sprintf("%03d", randInt(0, 999))
+
+	keyID, err := s.keys.ActiveKeyID()
+	if err != nil {
+		return nil, "", err
+	}
+	numEnc, err := s.keys.Encrypt(keyID, []byte(number))
+	if err != nil {
+		return nil, "", err
+	}
+	expEnc, err := s.keys.Encrypt(keyID, []byte(expiry))
+	if err != nil {
+		return nil, "", err
+	}
 
-	card := &model.Card{
+	cvvHash, err := bcrypt.GenerateFromPassword([]byte(cvv), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &model.Card{
 		AccountID:       accountID,
 		NumberEncrypted: numEnc,
 		ExpiryEncrypted: expEnc,
+		KeyID:           &keyID,
 		CVVHash:         string(cvvHash),
-		HMAC:            mac,
+		HMAC:            s.numberHMAC(number),
+		MaskedNumber:    pan.Mask(number),
+		PaymentSystem:   r.Brand,
+		Product:         r.Product,
+	}, cvv, nil
+}
+
+// binRange возвращает диапазон продукта; пустой product — первый настроенный.
+// Карты, выпущенные до появления продуктов, перевыпускаются в продукт по умолчанию.
+func (s *CardService) binRange(product string) (pan.Range, error) {
+	if product == "" {
+		return s.binRanges[0], nil
+	}
+	for _, r := range s.binRanges {
+		if r.Product == product {
+			return r, nil
+		}
+	}
+	return pan.Range{}, ErrUnknownProduct
+}
+
+// uniqueNumber генерирует номер, ещё не выданный ни одной карте.
+// Окончательно уникальность гарантирует индекс по cards.hmac.
+func (s *CardService) uniqueNumber(r pan.Range) (string, error) {
+	for i := 0; i < maxNumberAttempts; i++ {
+		number := pan.Generate(r)
+		_, err := s.cardRepo.GetByHMAC(s.numberHMAC(number))
+		if errors.Is(err, repository.ErrCardNotFound) {
+			return number, nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return "", repository.ErrCardNumberTaken
+}
+
+// numberHMAC — HMAC-SHA256 номера карты, по которому карта ищется без расшифровки.
+func (s *CardService) numberHMAC(number string) string {
+	h := hmac.New(sha256.New, s.hmacSecret)
+	h.Write([]byte(number))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// CardByPAN находит карту по открытому номеру.
+func (s *CardService) CardByPAN(pan string) (*model.Card, error) {
+	return s.cardRepo.GetByHMAC(s.numberHMAC(pan))
+}
+
+// Number расшифровывает номер карты.
+func (s *CardService) Number(c *model.Card) (string, error) {
+	numPlain, err := s.keys.Decrypt(c.KeyID, c.NumberEncrypted)
+	if err != nil {
+		return "", err
+	}
+	return string(numPlain), nil
+}
+
+// Expiry расшифровывает срок действия карты: первое число месяца срока.
+func (s *CardService) Expiry(c *model.Card) (time.Time, error) {
+	expPlain, err := s.keys.Decrypt(c.KeyID, c.ExpiryEncrypted)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Parse(cardExpiryLayout, string(expPlain))
+}
+
+// VerifyCVV сверяет CVV с сохранённым bcrypt-хешем.
+func (s *CardService) VerifyCVV(c *model.Card, cvv string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(c.CVVHash), []byte(cvv)) == nil
+}
+
+// Account возвращает счёт карты.
+func (s *CardService) Account(c *model.Card) (*model.Account, error) {
+	return s.acctRepo.GetByID(c.AccountID)
+}
+
+// Block блокирует карту по просьбе владельца.
+func (s *CardService) Block(userID, cardID int) (*model.Card, error) {
+	c, err := s.ownedCard(userID, cardID)
+	if err != nil {
+		return nil, err
 	}
+	if err := s.cardRepo.UpdateStatus(c, []string{model.CardActive}, model.CardBlockedUser); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
 
-	if err := s.cardRepo.Create(card); err != nil {
+// Unblock снимает блокировку владельца; блокировку банка снять нельзя.
+func (s *CardService) Unblock(userID, cardID int) (*model.Card, error) {
+	c, err := s.ownedCard(userID, cardID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.cardRepo.UpdateStatus(c, []string{model.CardBlockedUser}, model.CardActive); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// BankBlock блокирует карту по решению банка.
+func (s *CardService) BankBlock(operatorID, cardID int) (*model.Card, error) {
+	c, err := s.cardRepo.GetByID(cardID)
+	if err != nil {
+		return nil, err
+	}
+	from := []string{model.CardActive, model.CardBlockedUser, model.CardBlockedPIN}
+	if err := s.cardRepo.UpdateStatus(c, from, model.CardBlockedBank); err != nil {
+		return nil, err
+	}
+	log.Printf("Оператор #%d заблокировал карту #%d", operatorID, cardID)
+	return c, nil
+}
+
+func (s *CardService) BankUnblock(operatorID, cardID int) (*model.Card, error) {
+	c, err := s.cardRepo.GetByID(cardID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.cardRepo.UpdateStatus(c, []string{model.CardBlockedBank}, model.CardActive); err != nil {
+		return nil, err
+	}
+	log.Printf("Оператор #%d разблокировал карту #%d", operatorID, cardID)
+	return c, nil
+}
+
+// Reissue выпускает взамен карты новую к тому же счёту: с новым номером,
+// сроком и CVV. Старая карта получает статус reissued.
+func (s *CardService) Reissue(userID, cardID int) (*model.Card, error) {
+	old, err := s.ownedCard(userID, cardID)
+	if err != nil {
+		return nil, err
+	}
+	if old.Kind != model.CardKindPhysical {
+		return nil, ErrNotReissuable
+	}
+	card, _, err := s.newCard(old.AccountID, old.Product, time.Now().AddDate(3, 0, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.cardRepo.CreateTx(tx, card); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	from := []string{model.CardActive, model.CardBlockedUser, model.CardBlockedPIN, model.CardExpired}
+	if err := s.cardRepo.ReissueTx(tx, old, from, card.ID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
 	return card, nil
 }
 
+// Transactions возвращает страницу истории операций по карте владельца.
+func (s *CardService) Transactions(userID, cardID, limit, offset int) ([]*model.CardTransaction, error) {
+	c, err := s.ownedCard(userID, cardID)
+	if err != nil {
+		return nil, err
+	}
+	return s.cardTxRepo.ListByCard(c.ID, limit, offset)
+}
+
+// Controls возвращает ограничения по карте владельца.
+func (s *CardService) Controls(userID, cardID int) (*model.CardControls, error) {
+	c, err := s.ownedCard(userID, cardID)
+	if err != nil {
+		return nil, err
+	}
+	return s.cardRepo.GetControls(c.ID)
+}
+
+// UpdateControls заменяет ограничения по карте целиком.
+func (s *CardService) UpdateControls(userID, cardID int, ctl *model.CardControls) (*model.CardControls, error) {
+	c, err := s.ownedCard(userID, cardID)
+	if err != nil {
+		return nil, err
+	}
+	if ctl.AllowedMCC == nil {
+		ctl.AllowedMCC = []string{}
+	}
+	if ctl.AllowedCountries == nil {
+		ctl.AllowedCountries = []string{}
+	}
+	if err := s.cardRepo.UpdateControls(c.ID, ctl); err != nil {
+		return nil, err
+	}
+	return ctl, nil
+}
+
+// LockForAuthorization блокирует активную карту в транзакции авторизации:
+// авторизации по одной карте выполняются по очереди. Одноразовая карта
+// сразу помечается использованной — из параллельных покупок пройдёт одна;
+// при отказе транзакция откатывается и карта остаётся активной.
+func (s *CardService) LockForAuthorization(tx *sql.Tx, c *model.Card) error {
+	if c.Kind == model.CardKindSingleUse {
+		return s.cardRepo.ClaimSingleUseTx(tx, c)
+	}
+	return s.cardRepo.LockActiveTx(tx, c)
+}
+
+// ControlsFor возвращает ограничения карты без проверки владельца — для авторизации покупок.
+func (s *CardService) ControlsFor(c *model.Card) (*model.CardControls, error) {
+	return s.cardRepo.GetControls(c.ID)
+}
+
+// SetPIN устанавливает PIN после повторной аутентификации. Подходит и для
+// сброса забытого PIN: счётчик попыток обнуляется, блокировка по PIN снимается.
+func (s *CardService) SetPIN(userID, cardID int, req *model.CardPINSet, ip string) error {
+	err := s.setPIN(userID, cardID, req)
+	details := ""
+	if err != nil {
+		details = err.Error()
+	}
+	s.auditSvc.Record(userID, model.AuditCardPINSet, cardID, err == nil, ip, details)
+	return err
+}
+
+func (s *CardService) setPIN(userID, cardID int, req *model.CardPINSet) error {
+	if err := s.authSvc.VerifyStepUp(userID, &req.StepUp); err != nil {
+		return err
+	}
+	c, err := s.ownedCard(userID, cardID)
+	if err != nil {
+		return err
+	}
+	if c.Status != model.CardActive && c.Status != model.CardBlockedUser && c.Status != model.CardBlockedPIN {
+		return repository.ErrCardStatus
+	}
+	return s.storePIN(c, req.PIN)
+}
+
+// ChangePIN меняет PIN по текущему; неверный текущий PIN расходует попытку.
+func (s *CardService) ChangePIN(userID, cardID int, req *model.CardPINChange) error {
+	c, err := s.ownedCard(userID, cardID)
+	if err != nil {
+		return err
+	}
+	if err := s.VerifyPIN(c, req.CurrentPIN); err != nil {
+		return err
+	}
+	return s.storePIN(c, req.NewPIN)
+}
+
+// VerifyPIN проверяет PIN карты и ведёт счётчик неверных попыток.
+func (s *CardService) VerifyPIN(c *model.Card, pin string) error {
+	if c.Status == model.CardBlockedPIN {
+		return ErrPINBlocked
+	}
+	if c.PINHash == "" {
+		return ErrPINNotSet
+	}
+	ok, err := checkPIN(c.PINHash, pin)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if err := s.cardRepo.RegisterPINFailure(c, maxPINAttempts); err != nil {
+			return err
+		}
+		if c.Status == model.CardBlockedPIN {
+			return ErrPINBlocked
+		}
+		return fmt.Errorf("%w: %d attempts left", ErrInvalidPIN, maxPINAttempts-c.PINAttempts)
+	}
+	if c.PINAttempts > 0 {
+		return s.cardRepo.ResetPINAttempts(c)
+	}
+	return nil
+}
+
+func (s *CardService) storePIN(c *model.Card, pin string) error {
+	if weakPIN(pin) {
+		return ErrWeakPIN
+	}
+	pinHash, err := hashPIN(pin)
+	if err != nil {
+		return err
+	}
+	return s.cardRepo.SetPIN(c, pinHash)
+}
+
+// ExpireCards переводит в expired активные и заблокированные владельцем карты
+// с истёкшим сроком; вызывается шедулером. Карта действует до конца месяца срока.
+func (s *CardService) ExpireCards() error {
+	now := time.Now()
+	for _, status := range []string{model.CardActive, model.CardBlockedUser, model.CardBlockedPIN} {
+		cards, err := s.cardRepo.ListByStatus(status)
+		if err != nil {
+			return err
+		}
+		for _, c := range cards {
+			if c.ValidUntil != nil && now.After(*c.ValidUntil) {
+				err := s.cardRepo.UpdateStatus(c, []string{status}, model.CardExpired)
+				if err != nil && !errors.Is(err, repository.ErrCardStatus) {
+					return err
+				}
+				continue
+			}
+			exp, err := s.Expiry(c)
+			if err != nil {
+				log.Printf("Карта #%d: не удалось прочитать срок действия: %v", c.ID, err)
+				continue
+			}
+			if now.Before(exp.AddDate(0, 1, 0)) {
+				continue
+			}
+			err = s.cardRepo.UpdateStatus(c, []string{status}, model.CardExpired)
+			if err != nil && !errors.Is(err, repository.ErrCardStatus) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *CardService) ownedCard(userID, cardID int) (*model.Card, error) {
+	c, err := s.cardRepo.GetByID(cardID)
+	if err != nil {
+		return nil, err
+	}
+	acc, err := s.acctRepo.GetByID(c.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if acc.UserID != userID {
+		return nil, ErrCardNotYours
+	}
+	return c, nil
+}
+
 func (s *CardService) ListCards(userID int) ([]*model.CardResponse, error) {
 	accounts, err := s.acctRepo.ListByUser(userID)
 	if err != nil {
@@ -107,129 +595,157 @@ func (s *CardService) ListCards(userID int) ([]*model.CardResponse, error) {
 			return nil, err
 		}
 		for _, c := range cards {
-			numPlain, err := decryptWithPGP(s.privKeyPath, s.privKeyPassphrase, c.NumberEncrypted)
-			if err != nil {
-				return nil, err
-			}
-			expPlain, err := decryptWithPGP(s.privKeyPath, s.privKeyPassphrase, c.ExpiryEncrypted)
-			if err != nil {
-				return nil, err
+			if c.MaskedNumber == "" {
+				if err := s.backfillMasked(c); err != nil {
+					return nil, err
+				}
 			}
 			out = append(out, &model.CardResponse{
-				ID:        c.ID,
-				Number:    string(numPlain),
-				Expiry:    string(expPlain),
-				CreatedAt: c.CreatedAt,
+				ID:            c.ID,
+				AccountID:     c.AccountID,
+				MaskedNumber:  c.MaskedNumber,
+				PaymentSystem: c.PaymentSystem,
+				Product:       c.Product,
+				Kind:          c.Kind,
+				SpendCap:      c.SpendCap,
+				ValidUntil:    c.ValidUntil,
+				Status:        c.Status,
+				ReplacedBy:    c.ReplacedBy,
+				CreatedAt:     c.CreatedAt,
 			})
 		}
 	}
 	return out, nil
 }
 
-func randInt(min, max int) int {
-	n, _ := rand.Int(rand.Reader,
-		big.NewInt(int64(max-min+1)))
-	return int(n.Int64()) + min
-}
-
-func generateLuhnNumber(length int) string {
-	digits := make([]int, length)
-	for i := 0; i < length-1; i++ {
-		digits[i] = randInt(0, 9)
-	}
-	sum := 0
-	for i := 0; i < length-1; i++ {
-		d := digits[length-2-i]
-		if i%2 == 0 {
-			d *= 2
-			if d > 9 {
-				d -= 9
-			}
-		}
-		sum += d
+// Reveal возвращает полные номер и срок одной карты. Требует повторной
+// аутентификации, ограничен revealMaxAttempts попытками за revealWindow;
+// каждая попытка пишется в журнал аудита.
+func (s *CardService) Reveal(userID, cardID int, stepUp *model.StepUp, ip string) (*model.CardReveal, error) {
+	if err := s.auditSvc.CheckRate(userID, model.AuditCardReveal, revealMaxAttempts, revealWindow); err != nil {
+		return nil, err
 	}
-	check := (10 - (sum % 10)) % 10
-	digits[length-1] = check
-	s := ""
-	for _, d := range digits {
-		s += strconv.Itoa(d)
+	reveal, err := s.reveal(userID, cardID, stepUp)
+	details := ""
+	if err != nil {
+		details = err.Error()
 	}
-	return s
+	s.auditSvc.Record(userID, model.AuditCardReveal, cardID, err == nil, ip, details)
+	return reveal, err
 }
 
-func encryptWithPGP(pubKeyPath string, data []byte) ([]byte, error) {
-	f, err := os.Open(pubKeyPath)
-	if err != nil {
+func (s *CardService) reveal(userID, cardID int, stepUp *model.StepUp) (*model.CardReveal, error) {
+	if err := s.authSvc.VerifyStepUp(userID, stepUp); err != nil {
 		return nil, err
 	}
-	defer f.Close()
-	entityList, err := openpgp.ReadArmoredKeyRing(f)
+	c, err := s.ownedCard(userID, cardID)
 	if err != nil {
 		return nil, err
 	}
-	buf := &bytes.Buffer{}
-	w, err := armor.Encode(buf, "PGP MESSAGE", nil)
+	numPlain, err := s.keys.Decrypt(c.KeyID, c.NumberEncrypted)
 	if err != nil {
 		return nil, err
 	}
-	pt, err := openpgp.Encrypt(w, entityList, nil, nil, nil)
+	expPlain, err := s.keys.Decrypt(c.KeyID, c.ExpiryEncrypted)
 	if err != nil {
 		return nil, err
 	}
-	if _, err := pt.Write(data); err != nil {
-		return nil, err
-	}
-	pt.Close()
-	w.Close()
-	return buf.Bytes(), nil
+	return &model.CardReveal{
+		ID:     c.ID,
+		Number: string(numPlain),
+		Expiry: string(expPlain),
+	}, nil
 }
 
-func decryptWithPGP(privKeyPath, passphrase string, cipher []byte) ([]byte, error) {
-	keyFile, err := os.Open(privKeyPath)
+// backfillMasked заполняет маскированный номер карт, выпущенных до его появления.
+func (s *CardService) backfillMasked(c *model.Card) error {
+	numPlain, err := s.keys.Decrypt(c.KeyID, c.NumberEncrypted)
 	if err != nil {
-		return nil, fmt.Errorf("open privKey: %w", err)
+		return err
 	}
-	defer keyFile.Close()
+	c.MaskedNumber = pan.Mask(string(numPlain))
+	c.PaymentSystem = pan.Brand(string(numPlain))
+	return s.cardRepo.SetMasked(c)
+}
 
-	entityList, err := openpgp.ReadArmoredKeyRing(keyFile)
+// ReencryptCards перешифровывает активным ключом реквизиты карт, зашифрованные
+// прежними ключами или напрямую мастер-ключом. Карты обрабатываются пачками,
+// поэтому перешифрование идёт без остановки сервиса; вызывается шедулером и после ротации.
+func (s *CardService) ReencryptCards() error {
+	keyID, err := s.keys.ActiveKeyID()
 	if err != nil {
-		return nil, fmt.Errorf("read key ring: %w", err)
-	}
-	if len(entityList) == 0 {
-		return nil, errors.New("no PGP entities found")
+		return err
 	}
-
-	for _, ent := range entityList {
-		if ent.PrivateKey != nil && ent.PrivateKey.Encrypted {
-			if err := ent.PrivateKey.Decrypt([]byte(passphrase)); err != nil {
-				return nil, fmt.Errorf("decrypt privKey: %w", err)
-			}
+	done, failed, afterID := 0, 0, 0
+	for {
+		cards, err := s.cardRepo.ListByStaleKey(keyID, afterID, reencryptBatch)
+		if err != nil {
+			return err
 		}
-		for _, sub := range ent.Subkeys {
-			if sub.PrivateKey != nil && sub.PrivateKey.Encrypted {
-				if err := sub.PrivateKey.Decrypt([]byte(passphrase)); err != nil {
-					return nil, fmt.Errorf("decrypt subkey: %w", err)
-				}
+		if len(cards) == 0 {
+			break
+		}
+		for _, c := range cards {
+			afterID = c.ID
+			err := s.reencrypt(c, keyID)
+			switch {
+			case err == nil:
+				done++
+			case errors.Is(err, repository.ErrCardKeyChanged):
+				// карту уже перешифровал параллельный запуск
+			default:
+				failed++
+				log.Printf("Не удалось перешифровать карту %d: %v", c.ID, err)
 			}
 		}
 	}
+	if done > 0 || failed > 0 {
+		log.Printf("Перешифровано карт: %d, ошибок: %d", done, failed)
+	}
+	return nil
+}
 
-	block, err := armor.Decode(bytes.NewReader(cipher))
+func (s *CardService) reencrypt(c *model.Card, keyID int) error {
+	numPlain, err := s.keys.Decrypt(c.KeyID, c.NumberEncrypted)
 	if err != nil {
-		return nil, fmt.Errorf("armor decode: %w", err)
+		return err
 	}
-
-	promptFunc := func(keys []openpgp.Key, symmetric bool) ([]byte, error) {
-		return []byte(passphrase), nil
+	expPlain, err := s.keys.Decrypt(c.KeyID, c.ExpiryEncrypted)
+	if err != nil {
+		return err
+	}
+	numEnc, err := s.keys.Encrypt(keyID, numPlain)
+	if err != nil {
+		return err
 	}
-	md, err := openpgp.ReadMessage(block.Body, entityList, promptFunc, nil)
+	expEnc, err := s.keys.Encrypt(keyID, expPlain)
 	if err != nil {
-		return nil, fmt.Errorf("read message: %w", err)
+		return err
 	}
+	return s.cardRepo.SetEncryption(c, keyID, numEnc, expEnc)
+}
 
-	plain, err := io.ReadAll(md.UnverifiedBody)
+// RotateKey создаёт новый ключ шифрования и запускает перешифрование карт в фоне.
+func (s *CardService) RotateKey() (*model.KeyRotation, error) {
+	k, err := s.keys.Rotate()
+	if err != nil {
+		return nil, err
+	}
+	pending, err := s.cardRepo.CountByStaleKey(k.ID)
 	if err != nil {
-		return nil, fmt.Errorf("read plaintext: %w", err)
+		return nil, err
 	}
-	return plain, nil
+	log.Printf("Ротация ключа шифрования: новый ключ %d, карт к перешифрованию: %d", k.ID, pending)
+	go func() {
+		if err := s.ReencryptCards(); err != nil {
+			log.Printf("Ошибка перешифрования карт: %v", err)
+		}
+	}()
+	return &model.KeyRotation{Key: k, PendingCards: pending}, nil
+}
+
+func randInt(min, max int) int {
+	n, _ := rand.Int(rand.Reader,
+		big.NewInt(int64(max-min+1)))
+	return int(n.Int64()) + min
 }
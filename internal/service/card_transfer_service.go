@@ -0,0 +1,62 @@
+package service
+
+import (
+	"Bank/internal/model"
+	"Bank/internal/pan"
+	"Bank/internal/repository"
+	"errors"
+)
+
+var (
+	ErrInvalidCardNumber     = errors.New("invalid card number")
+	ErrRecipientCardNotFound = errors.New("recipient card not found")
+	ErrRecipientCardInactive = errors.New("recipient card is not active")
+	ErrCardOnSourceAccount   = errors.New("recipient card is linked to the source account")
+)
+
+// CardTransferService переводит деньги по номеру карты получателя: номер
+// находится по HMAC, зачисление идёт на счёт карты обычным переводом.
+type CardTransferService struct {
+	cardSvc *CardService
+	accSvc  *AccountService
+}
+
+func NewCardTransferService(cardSvc *CardService, accSvc *AccountService) *CardTransferService {
+	return &CardTransferService{cardSvc: cardSvc, accSvc: accSvc}
+}
+
+func (s *CardTransferService) Transfer(userID int, req *model.CardTransferCreate) (*model.TransferResult, error) {
+	c, err := s.Recipient(req.ToCard)
+	if err != nil {
+		return nil, err
+	}
+	if c.AccountID == req.FromAccountID {
+		return nil, ErrCardOnSourceAccount
+	}
+	if err := s.accSvc.AuthorizeTransfer(userID, req.Amount, req.OTP); err != nil {
+		return nil, err
+	}
+	return s.accSvc.TransferWithNote(userID, req.FromAccountID, c.AccountID, req.Amount, "card "+c.MaskedNumber)
+}
+
+// Recipient находит карту получателя. Зачисление возможно только на
+// активную карту: на заблокированную, просроченную или перевыпущенную — нет.
+func (s *CardTransferService) Recipient(number string) (*model.Card, error) {
+	if !pan.Valid(number) {
+		return nil, ErrInvalidCardNumber
+	}
+	c, err := s.cardSvc.CardByPAN(number)
+	if errors.Is(err, repository.ErrCardNotFound) {
+		return nil, ErrRecipientCardNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if c.Status != model.CardActive {
+		return nil, ErrRecipientCardInactive
+	}
+	if c.MaskedNumber == "" {
+		c.MaskedNumber = pan.Mask(number)
+	}
+	return c, nil
+}
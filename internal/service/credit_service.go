@@ -101,6 +101,23 @@ func (s *CreditService) CreateCredit(userID int, req *model.CreditCreate) (*mode
 	return credit, schedules, nil
 }
 
+// ListCredits возвращает кредиты по всем счетам пользователя.
+func (s *CreditService) ListCredits(userID int) ([]*model.Credit, error) {
+	accounts, err := s.accountRepo.ListByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	var out []*model.Credit
+	for _, acc := range accounts {
+		credits, err := s.creditRepo.ListByAccount(acc.ID)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, credits...)
+	}
+	return out, nil
+}
+
 func (s *CreditService) GetSchedule(userID, creditID int) ([]*model.PaymentSchedule, error) {
 	cr, err := s.creditRepo.GetByID(creditID)
 	if err != nil {
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"Bank/internal/model"
+	"Bank/internal/repository"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+)
+
+var ErrNotDisputable = errors.New("only outgoing transactions can be disputed")
+
+// disputeStatusTitles — формулировки статусов спора для писем клиенту.
+var disputeStatusTitles = map[string]string{
+	model.DisputeOpen:            "открыт",
+	model.DisputeUnderReview:     "взят на рассмотрение",
+	model.DisputeResolvedFavor:   "решён в вашу пользу",
+	model.DisputeResolvedAgainst: "решён не в вашу пользу",
+}
+
+// DisputeService ведёт споры клиентов по операциям. Решение в пользу
+// клиента отменяет операцию через ReversalService.
+type DisputeService struct {
+	disputeRepo repository.DisputeRepository
+	txRepo      repository.TransactionRepository
+	accountRepo repository.AccountRepository
+	userRepo    repository.UserRepository
+	reversalSvc *ReversalService
+	mailSvc     MailService
+}
+
+func NewDisputeService(
+	dr repository.DisputeRepository,
+	tr repository.TransactionRepository,
+	ar repository.AccountRepository,
+	ur repository.UserRepository,
+	reversalSvc *ReversalService,
+	mailSvc MailService,
+) *DisputeService {
+	return &DisputeService{
+		disputeRepo: dr,
+		txRepo:      tr,
+		accountRepo: ar,
+		userRepo:    ur,
+		reversalSvc: reversalSvc,
+		mailSvc:     mailSvc,
+	}
+}
+
+// Open открывает спор по операции на счёте пользователя.
+func (s *DisputeService) Open(userID, transactionID int, req *model.DisputeCreate) (*model.Dispute, error) {
+	t, err := s.txRepo.GetByID(transactionID)
+	if err != nil {
+		return nil, err
+	}
+	acc, err := s.accountRepo.GetByID(t.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if acc.UserID != userID {
+		return nil, ErrAccessDenied
+	}
+	if t.ReversalOf != nil || isCredit(t) {
+		return nil, ErrNotDisputable
+	}
+
+	d := &model.Dispute{
+		TransactionID: transactionID,
+		UserID:        userID,
+		Reason:        req.Reason,
+	}
+	if err := s.disputeRepo.Create(d); err != nil {
+		return nil, err
+	}
+	s.notify(d)
+	return d, nil
+}
+
+func (s *DisputeService) List(userID int) ([]*model.Dispute, error) {
+	return s.disputeRepo.ListByUser(userID)
+}
+
+func (s *DisputeService) Get(userID, disputeID int) (*model.Dispute, error) {
+	d, err := s.disputeRepo.GetByID(disputeID)
+	if err != nil {
+		return nil, err
+	}
+	if d.UserID != userID {
+		return nil, ErrAccessDenied
+	}
+	return d, nil
+}
+
+// Queue — споры для операторов, отфильтрованные по статусу.
+func (s *DisputeService) Queue(status string) ([]*model.Dispute, error) {
+	return s.disputeRepo.ListByStatus(status)
+}
+
+// Review берёт открытый спор на рассмотрение.
+func (s *DisputeService) Review(operatorID, disputeID int) (*model.Dispute, error) {
+	d, err := s.disputeRepo.GetByID(disputeID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.disputeRepo.UpdateStatus(d, []string{model.DisputeOpen}, model.DisputeUnderReview, d.Resolution); err != nil {
+		return nil, err
+	}
+	log.Printf("Оператор #%d взял на рассмотрение спор #%d", operatorID, disputeID)
+	s.notify(d)
+	return d, nil
+}
+
+// Resolve закрывает спор. При решении в пользу клиента операция отменяется
+// до смены статуса: если отмена не удалась, спор остаётся незакрытым.
+func (s *DisputeService) Resolve(operatorID, disputeID int, req *model.DisputeResolve) (*model.Dispute, error) {
+	d, err := s.disputeRepo.GetByID(disputeID)
+	if err != nil {
+		return nil, err
+	}
+	if d.Status != model.DisputeOpen && d.Status != model.DisputeUnderReview {
+		return nil, repository.ErrDisputeStatusChanged
+	}
+
+	status := model.DisputeResolvedAgainst
+	if req.Outcome == "favor" {
+		status = model.DisputeResolvedFavor
+		reason := fmt.Sprintf("dispute:%d", d.ID)
+		if _, err := s.reversalSvc.Reverse(operatorID, d.TransactionID, reason); err != nil &&
+			!errors.Is(err, repository.ErrAlreadyReversed) {
+			return nil, err
+		}
+	}
+
+	from := []string{model.DisputeOpen, model.DisputeUnderReview}
+	if err := s.disputeRepo.UpdateStatus(d, from, status, req.Resolution); err != nil {
+		return nil, err
+	}
+	log.Printf("Оператор #%d закрыл спор #%d: %s", operatorID, disputeID, status)
+	s.notify(d)
+	return d, nil
+}
+
+func (s *DisputeService) notify(d *model.Dispute) {
+	user, err := s.userRepo.GetByID(d.UserID)
+	if err != nil {
+		return
+	}
+	body := fmt.Sprintf(
+		"<h1>Спор #%d</h1>"+
+			"<p>Спор по операции #%d %s.</p>",
+		d.ID, d.TransactionID, disputeStatusTitles[d.Status],
+	)
+	if d.Resolution != "" {
+		body += fmt.Sprintf("<p>Комментарий банка: %s</p>", html.EscapeString(d.Resolution))
+	}
+	_ = s.mailSvc.Send(user.Email, "Статус спора по операции", body)
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"Bank/internal/model"
+	"Bank/internal/repository"
+	"database/sql"
+	"errors"
+	"math"
+	"time"
+)
+
+// FeeService рассчитывает комиссии по тарифам из fee_schedules и
+// проводит их отдельными операциями типа fee.
+type FeeService struct {
+	feeRepo repository.FeeRepository
+	txRepo  repository.TransactionRepository
+}
+
+func NewFeeService(fr repository.FeeRepository, tr repository.TransactionRepository) *FeeService {
+	return &FeeService{feeRepo: fr, txRepo: tr}
+}
+
+func (s *FeeService) Schedules() ([]*model.FeeSchedule, error) {
+	return s.feeRepo.List()
+}
+
+// Quote рассчитывает комиссию с учётом бесплатных операций в текущем месяце.
+// Если тариф для операции не задан, комиссия нулевая. Расчёт предварительный:
+// бесплатную операцию окончательно засчитывает ClaimTx.
+func (s *FeeService) Quote(userID int, operation string, amount float64) (*model.FeeQuote, error) {
+	q := &model.FeeQuote{Operation: operation, Amount: amount}
+
+	sch, err := s.feeRepo.GetByOperation(operation)
+	if errors.Is(err, repository.ErrFeeScheduleNotFound) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if sch.FreePerMonth > 0 {
+		used, err := s.feeRepo.UsageCount(userID, operation, monthStart(time.Now()))
+		if err != nil {
+			return nil, err
+		}
+		if used < sch.FreePerMonth {
+			q.Waived = true
+			q.FreeRemaining = sch.FreePerMonth - used - 1
+			return q, nil
+		}
+	}
+
+	q.Fee = computeFee(sch, amount)
+	return q, nil
+}
+
+// ClaimTx подтверждает расчёт в транзакции операции: бесплатная операция
+// засчитывается, только если параллельные операции ещё не исчерпали квоту;
+// иначе в q проставляется комиссия по тарифу. Вызывается до проверки баланса.
+func (s *FeeService) ClaimTx(tx *sql.Tx, userID int, q *model.FeeQuote) error {
+	if !q.Waived {
+		return nil
+	}
+	sch, err := s.feeRepo.GetByOperation(q.Operation)
+	if errors.Is(err, repository.ErrFeeScheduleNotFound) {
+		// тариф отключили после расчёта — операция бесплатна и без квоты
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	ok, err := s.feeRepo.TakeFreeUsage(tx, userID, q.Operation, monthStart(time.Now()), sch.FreePerMonth)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		q.Waived = false
+		q.FreeRemaining = 0
+		q.Fee = computeFee(sch, q.Amount)
+	}
+	return nil
+}
+
+// Record создаёт операцию fee, если комиссия ненулевая. Баланс счёта
+// вызывающий код меняет сам — в той же транзакции.
+func (s *FeeService) Record(tx *sql.Tx, accountID int, q *model.FeeQuote) (*model.Transaction, error) {
+	if q.Fee <= 0 {
+		return nil, nil
+	}
+	t := &model.Transaction{
+		AccountID:   accountID,
+		Amount:      q.Fee,
+		Type:        "fee",
+		Direction:   model.TxDebit,
+		Description: "fee:" + q.Operation,
+	}
+	if err := s.txRepo.CreateTx(tx, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func computeFee(sch *model.FeeSchedule, amount float64) float64 {
+	var fee float64
+	switch sch.RuleType {
+	case model.FeeRuleFlat:
+		fee = sch.FlatAmount
+	case model.FeeRulePercent:
+		fee = sch.FlatAmount + amount*sch.Percent/100
+	case model.FeeRuleTiered:
+		for _, t := range sch.Tiers {
+			if t.UpTo == nil || amount <= *t.UpTo {
+				fee = t.Flat + amount*t.Percent/100
+				break
+			}
+		}
+	}
+	if fee <= 0 {
+		return 0
+	}
+	if fee < sch.MinFee {
+		fee = sch.MinFee
+	}
+	if sch.MaxFee > 0 && fee > sch.MaxFee {
+		fee = sch.MaxFee
+	}
+	return math.Round(fee*100) / 100
+}
+
+// monthStart — полночь первого числа месяца в часовом поясе t. Бесплатные
+// операции по тарифам и месячные лимиты считаются по одной границе.
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
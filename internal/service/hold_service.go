@@ -0,0 +1,209 @@
+package service
+
+import (
+	"Bank/internal/model"
+	"Bank/internal/repository"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+)
+
+const defaultHoldTTL = 7 * 24 * time.Hour
+
+var (
+	ErrCaptureExceedsHold = errors.New("capture amount exceeds held amount")
+	ErrCardHold           = errors.New("card authorization hold cannot be released by the account owner")
+)
+
+// HoldService управляет двухфазными операциями: сначала сумма блокируется
+// на счёте (Create, AuthorizeTx), затем списывается (Capture) или освобождается (Release).
+type HoldService struct {
+	db          *sql.DB
+	holdRepo    repository.HoldRepository
+	accountRepo repository.AccountRepository
+	txRepo      repository.TransactionRepository
+}
+
+func NewHoldService(
+	db *sql.DB,
+	hr repository.HoldRepository,
+	ar repository.AccountRepository,
+	tr repository.TransactionRepository,
+) *HoldService {
+	return &HoldService{
+		db:          db,
+		holdRepo:    hr,
+		accountRepo: ar,
+		txRepo:      tr,
+	}
+}
+
+// Create ставит холд по запросу владельца счёта.
+func (s *HoldService) Create(userID, accountID int, req *model.HoldCreate) (*model.Hold, error) {
+	if _, err := s.ownedAccount(userID, accountID); err != nil {
+		return nil, err
+	}
+	ttl := defaultHoldTTL
+	if req.ExpiresInHours > 0 {
+		ttl = time.Duration(req.ExpiresInHours) * time.Hour
+	}
+	return s.place(accountID, req.Amount, model.HoldSourceCustomer, req.Description, ttl)
+}
+
+// AuthorizeTx ставит холд карточной авторизации в транзакции tx без проверки
+// владельца. Такой холд списывается клирингом или снимается реверсалом и шедулером.
+func (s *HoldService) AuthorizeTx(tx *sql.Tx, accountID int, amount float64, description string, ttl time.Duration) (*model.Hold, error) {
+	return s.placeTx(tx, accountID, amount, model.HoldSourceCard, description, ttl)
+}
+
+func (s *HoldService) place(accountID int, amount float64, source, description string, ttl time.Duration) (*model.Hold, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	h, err := s.placeTx(tx, accountID, amount, source, description, ttl)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+func (s *HoldService) placeTx(tx *sql.Tx, accountID int, amount float64, source, description string, ttl time.Duration) (*model.Hold, error) {
+	h := &model.Hold{
+		AccountID:   accountID,
+		Amount:      amount,
+		Source:      source,
+		Description: description,
+		ExpiresAt:   time.Now().Add(ttl),
+	}
+	if err := s.holdRepo.CreateTx(tx, h); err != nil {
+		if errors.Is(err, repository.ErrHoldNotPlaced) {
+			return nil, ErrInsufficientFunds
+		}
+		return nil, err
+	}
+	return h, nil
+}
+
+func (s *HoldService) List(userID, accountID int) ([]*model.Hold, error) {
+	if _, err := s.ownedAccount(userID, accountID); err != nil {
+		return nil, err
+	}
+	return s.holdRepo.ListByAccount(accountID)
+}
+
+// ReleaseOwned снимает холд по запросу владельца счёта. Списывать холды
+// клиент не может: это было бы снятием в обход лимитов и комиссий. Карточные
+// холды ему недоступны: иначе покупку можно было бы оплатить повторно.
+func (s *HoldService) ReleaseOwned(userID, holdID int) (*model.Hold, error) {
+	h, err := s.ownedHold(userID, holdID)
+	if err != nil {
+		return nil, err
+	}
+	return s.Release(h.ID)
+}
+
+// Capture списывает amount с учётного баланса (ноль — всю сумму холда)
+// проводкой типа txType. Остаток частично списанного холда освобождается.
+func (s *HoldService) Capture(holdID int, amount float64, txType string) (*model.Hold, error) {
+	h, err := s.holdRepo.GetByID(holdID)
+	if err != nil {
+		return nil, err
+	}
+	return s.capture(h, amount, txType)
+}
+
+func (s *HoldService) Release(holdID int) (*model.Hold, error) {
+	if err := s.holdRepo.Release(holdID); err != nil {
+		return nil, err
+	}
+	return s.holdRepo.GetByID(holdID)
+}
+
+// ExpireHolds освобождает холды с истёкшим сроком; вызывается шедулером.
+func (s *HoldService) ExpireHolds() error {
+	n, err := s.holdRepo.ExpireDue()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		log.Printf("Истекло холдов: %d", n)
+	}
+	return nil
+}
+
+func (s *HoldService) capture(h *model.Hold, amount float64, txType string) (*model.Hold, error) {
+	if h.Status != model.HoldActive || time.Now().After(h.ExpiresAt) {
+		return nil, repository.ErrHoldNotOpen
+	}
+	if amount == 0 {
+		amount = h.Amount
+	}
+	if amount > h.Amount {
+		return nil, ErrCaptureExceedsHold
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	// списание относительно текущего баланса: прочитанное заранее значение
+	// затёрло бы параллельные зачисления и списания
+	if err := s.accountRepo.AddBalanceTx(tx, h.AccountID, -amount); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	t := &model.Transaction{
+		AccountID:   h.AccountID,
+		Amount:      amount,
+		Type:        txType,
+		Direction:   model.TxDebit,
+		Description: h.Description,
+	}
+	if err := s.txRepo.CreateTx(tx, t); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := s.holdRepo.CaptureTx(tx, h.ID, amount, t.ID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	h.Status = model.HoldCaptured
+	h.CapturedAmount = amount
+	h.TransactionID = &t.ID
+	return h, nil
+}
+
+func (s *HoldService) ownedAccount(userID, accountID int) (*model.Account, error) {
+	acc, err := s.accountRepo.GetByID(accountID)
+	if err != nil {
+		return nil, err
+	}
+	if acc.UserID != userID {
+		return nil, ErrAccessDenied
+	}
+	return acc, nil
+}
+
+func (s *HoldService) ownedHold(userID, holdID int) (*model.Hold, error) {
+	h, err := s.holdRepo.GetByID(holdID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.ownedAccount(userID, h.AccountID); err != nil {
+		return nil, err
+	}
+	if h.Source != model.HoldSourceCustomer {
+		return nil, ErrCardHold
+	}
+	return h, nil
+}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"Bank/internal/model"
+	"Bank/internal/repository"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+)
+
+// dekSize — длина ключа шифрования данных (AES-256).
+const dekSize = 32
+
+var ErrCiphertextCorrupted = errors.New("ciphertext is too short")
+
+// KeyManager реализует конвертное шифрование: данные шифруются AES-GCM
+// ключом данных (DEK), а сами DEK хранятся в БД зашифрованными мастер-ключом
+// (PGP). Связки PGP читаются с диска один раз, расшифрованные DEK кешируются
+// в памяти. Ротация создаёт новый DEK; прежние остаются для расшифровки.
+type KeyManager struct {
+	keyRepo  repository.EncryptionKeyRepository
+	pubRing  openpgp.EntityList
+	privRing openpgp.EntityList
+	mu       sync.RWMutex
+	deks     map[int][]byte
+}
+
+func NewKeyManager(kr repository.EncryptionKeyRepository, pubKeyPath, privKeyPath, passphrase string) (*KeyManager, error) {
+	pubRing, err := readKeyRing(pubKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("public key: %w", err)
+	}
+	privRing, err := readKeyRing(privKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("private key: %w", err)
+	}
+	if err := unlockKeyRing(privRing, passphrase); err != nil {
+		return nil, err
+	}
+	return &KeyManager{
+		keyRepo:  kr,
+		pubRing:  pubRing,
+		privRing: privRing,
+		deks:     make(map[int][]byte),
+	}, nil
+}
+
+// ActiveKeyID возвращает идентификатор текущего ключа; при первом запуске
+// ключ создаётся. Активный ключ читается из БД, чтобы ротация на одном
+// экземпляре сервиса сразу подхватывалась остальными.
+func (m *KeyManager) ActiveKeyID() (int, error) {
+	k, err := m.keyRepo.GetActive()
+	if errors.Is(err, repository.ErrKeyNotFound) {
+		k, err = m.Rotate()
+	}
+	if err != nil {
+		return 0, err
+	}
+	return k.ID, nil
+}
+
+// Rotate создаёт новый активный DEK и выводит прежний из оборота.
+func (m *KeyManager) Rotate() (*model.EncryptionKey, error) {
+	dek := make([]byte, dekSize)
+	if _, err := rand.Read(dek); err != nil {
+		return nil, err
+	}
+	wrapped, err := m.wrap(dek)
+	if err != nil {
+		return nil, err
+	}
+	k := &model.EncryptionKey{WrappedKey: wrapped}
+	if err := m.keyRepo.Rotate(k); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	m.deks[k.ID] = dek
+	m.mu.Unlock()
+	return k, nil
+}
+
+// Encrypt шифрует plain ключом keyID. Результат — nonce, за которым следует шифртекст.
+func (m *KeyManager) Encrypt(keyID int, plain []byte) ([]byte, error) {
+	aead, err := m.aead(keyID)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plain, nil), nil
+}
+
+// Decrypt расшифровывает данные ключом keyID; nil — данные зашифрованы
+// напрямую мастер-ключом, как до появления DEK.
+func (m *KeyManager) Decrypt(keyID *int, data []byte) ([]byte, error) {
+	if keyID == nil {
+		return m.unwrap(data)
+	}
+	aead, err := m.aead(*keyID)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < aead.NonceSize() {
+		return nil, ErrCiphertextCorrupted
+	}
+	nonce, sealed := data[:aead.NonceSize()], data[aead.NonceSize():]
+	return aead.Open(nil, nonce, sealed, nil)
+}
+
+func (m *KeyManager) aead(keyID int) (cipher.AEAD, error) {
+	dek, err := m.dek(keyID)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(dek)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// dek возвращает расшифрованный ключ из кеша, при промахе — из БД.
+func (m *KeyManager) dek(keyID int) ([]byte, error) {
+	m.mu.RLock()
+	dek, ok := m.deks[keyID]
+	m.mu.RUnlock()
+	if ok {
+		return dek, nil
+	}
+
+	k, err := m.keyRepo.GetByID(keyID)
+	if err != nil {
+		return nil, err
+	}
+	dek, err = m.unwrap(k.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("unwrap key %d: %w", keyID, err)
+	}
+
+	m.mu.Lock()
+	m.deks[keyID] = dek
+	m.mu.Unlock()
+	return dek, nil
+}
+
+// wrap шифрует данные мастер-ключом (PGP).
+func (m *KeyManager) wrap(data []byte) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	w, err := armor.Encode(buf, "PGP MESSAGE", nil)
+	if err != nil {
+		return nil, err
+	}
+	pt, err := openpgp.Encrypt(w, m.pubRing, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := pt.Write(data); err != nil {
+		return nil, err
+	}
+	pt.Close()
+	w.Close()
+	return buf.Bytes(), nil
+}
+
+// unwrap расшифровывает данные мастер-ключом (PGP).
+func (m *KeyManager) unwrap(data []byte) ([]byte, error) {
+	block, err := armor.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("armor decode: %w", err)
+	}
+	md, err := openpgp.ReadMessage(block.Body, m.privRing, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("read message: %w", err)
+	}
+	plain, err := io.ReadAll(md.UnverifiedBody)
+	if err != nil {
+		return nil, fmt.Errorf("read plaintext: %w", err)
+	}
+	return plain, nil
+}
+
+func readKeyRing(path string) (openpgp.EntityList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	ring, err := openpgp.ReadArmoredKeyRing(f)
+	if err != nil {
+		return nil, fmt.Errorf("read key ring: %w", err)
+	}
+	if len(ring) == 0 {
+		return nil, errors.New("no PGP entities found")
+	}
+	return ring, nil
+}
+
+// unlockKeyRing один раз расшифровывает закрытые ключи связки паролем.
+func unlockKeyRing(ring openpgp.EntityList, passphrase string) error {
+	for _, ent := range ring {
+		if ent.PrivateKey != nil && ent.PrivateKey.Encrypted {
+			if err := ent.PrivateKey.Decrypt([]byte(passphrase)); err != nil {
+				return fmt.Errorf("decrypt privKey: %w", err)
+			}
+		}
+		for _, sub := range ent.Subkeys {
+			if sub.PrivateKey != nil && sub.PrivateKey.Encrypted {
+				if err := sub.PrivateKey.Decrypt([]byte(passphrase)); err != nil {
+					return fmt.Errorf("decrypt subkey: %w", err)
+				}
+			}
+		}
+	}
+	return nil
+}
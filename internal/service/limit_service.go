@@ -0,0 +1,215 @@
+package service
+
+import (
+	"Bank/internal/model"
+	"Bank/internal/repository"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+var (
+	ErrLimitExceeded      = errors.New("transaction limit exceeded")
+	ErrLimitAboveMaximum  = errors.New("limit cannot exceed the bank maximum")
+	ErrUnsupportedLimitOp = errors.New("unsupported limit operation")
+)
+
+// Limits — набор лимитов по одной операции.
+type Limits struct {
+	Daily           float64
+	Monthly         float64
+	PerOperationMax float64
+}
+
+// DefaultLimits — лимиты банка; пользователь может только понизить их.
+var DefaultLimits = map[string]Limits{
+	model.LimitOpWithdraw: {Daily: 300000, Monthly: 1000000, PerOperationMax: 150000},
+	model.LimitOpTransfer: {Daily: 1000000, Monthly: 5000000, PerOperationMax: 600000},
+	model.LimitOpPurchase: {Daily: 500000, Monthly: 2000000, PerOperationMax: 300000},
+}
+
+type LimitService struct {
+	limitRepo   repository.LimitRepository
+	accountRepo repository.AccountRepository
+}
+
+func NewLimitService(lr repository.LimitRepository, ar repository.AccountRepository) *LimitService {
+	return &LimitService{limitRepo: lr, accountRepo: ar}
+}
+
+// CheckTx проверяет, что операция на amount укладывается в лимиты
+// пользователя и, если заданы, в лимиты счёта. Вызывается в транзакции
+// операции до её проводок: строка пользователя блокируется, поэтому
+// параллельные операции не могут вместе превысить лимит.
+func (s *LimitService) CheckTx(tx *sql.Tx, userID, accountID int, operation string, amount float64) error {
+	if err := s.limitRepo.LockUserTx(tx, userID); err != nil {
+		return err
+	}
+	statuses, err := s.statuses(tx, userID, operation, accountID)
+	if err != nil {
+		return err
+	}
+	for _, st := range statuses {
+		scope := "user"
+		if st.AccountID != nil {
+			scope = fmt.Sprintf("account #%d", *st.AccountID)
+		}
+		switch {
+		case amount > st.PerOperationMax:
+			return fmt.Errorf("%w: %s %s per-operation maximum is %.2f", ErrLimitExceeded, scope, operation, st.PerOperationMax)
+		case amount > st.DailyRemaining:
+			return fmt.Errorf("%w: %s daily %s limit %.2f, remaining %.2f", ErrLimitExceeded, scope, operation, st.DailyLimit, st.DailyRemaining)
+		case amount > st.MonthlyRemaining:
+			return fmt.Errorf("%w: %s monthly %s limit %.2f, remaining %.2f", ErrLimitExceeded, scope, operation, st.MonthlyLimit, st.MonthlyRemaining)
+		}
+	}
+	return nil
+}
+
+// Status возвращает лимиты пользователя и всех счетов с собственными лимитами.
+func (s *LimitService) Status(userID int) ([]*model.LimitStatus, error) {
+	var out []*model.LimitStatus
+	for _, op := range []string{model.LimitOpWithdraw, model.LimitOpTransfer, model.LimitOpPurchase} {
+		st, err := s.statuses(nil, userID, op, 0)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, st...)
+	}
+	return out, nil
+}
+
+func (s *LimitService) Update(userID int, req *model.TransactionLimitUpdate) (*model.TransactionLimit, error) {
+	def, ok := DefaultLimits[req.Operation]
+	if !ok {
+		return nil, ErrUnsupportedLimitOp
+	}
+	if req.AccountID != nil {
+		acc, err := s.accountRepo.GetByID(*req.AccountID)
+		if err != nil {
+			return nil, err
+		}
+		if acc.UserID != userID {
+			return nil, ErrAccessDenied
+		}
+	}
+	if exceeds(req.DailyLimit, def.Daily) || exceeds(req.MonthlyLimit, def.Monthly) ||
+		exceeds(req.PerOperationMax, def.PerOperationMax) {
+		return nil, ErrLimitAboveMaximum
+	}
+
+	l := &model.TransactionLimit{
+		UserID:          userID,
+		AccountID:       req.AccountID,
+		Operation:       req.Operation,
+		DailyLimit:      req.DailyLimit,
+		MonthlyLimit:    req.MonthlyLimit,
+		PerOperationMax: req.PerOperationMax,
+	}
+	if err := s.limitRepo.Upsert(l); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// statuses считает использование лимитов операции: всегда на уровне
+// пользователя и для счетов с собственными настройками. Если onlyAccount
+// задан, из лимитов счетов берётся только он. Суммы читаются в tx, если он задан.
+func (s *LimitService) statuses(tx *sql.Tx, userID int, operation string, onlyAccount int) ([]*model.LimitStatus, error) {
+	def, ok := DefaultLimits[operation]
+	if !ok {
+		return nil, ErrUnsupportedLimitOp
+	}
+	settings, err := s.limitRepo.ListByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	userLimits := def
+	var accountSettings []*model.TransactionLimit
+	for _, l := range settings {
+		if l.Operation != operation {
+			continue
+		}
+		if l.AccountID == nil {
+			userLimits = merge(userLimits, l)
+		} else if onlyAccount == 0 || *l.AccountID == onlyAccount {
+			accountSettings = append(accountSettings, l)
+		}
+	}
+
+	now := time.Now()
+	dayStart := truncateDay(now)
+	firstOfMonth := monthStart(now)
+
+	daily, err := s.sumByUser(tx, userID, operation, dayStart)
+	if err != nil {
+		return nil, err
+	}
+	monthly, err := s.sumByUser(tx, userID, operation, firstOfMonth)
+	if err != nil {
+		return nil, err
+	}
+	out := []*model.LimitStatus{newLimitStatus(nil, operation, userLimits, daily, monthly)}
+
+	for _, l := range accountSettings {
+		daily, err := s.sumByAccount(tx, *l.AccountID, operation, dayStart)
+		if err != nil {
+			return nil, err
+		}
+		monthly, err := s.sumByAccount(tx, *l.AccountID, operation, firstOfMonth)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, newLimitStatus(l.AccountID, operation, merge(userLimits, l), daily, monthly))
+	}
+	return out, nil
+}
+
+func (s *LimitService) sumByUser(tx *sql.Tx, userID int, operation string, since time.Time) (float64, error) {
+	if tx != nil {
+		return s.limitRepo.SumByUserSinceTx(tx, userID, operation, since)
+	}
+	return s.limitRepo.SumByUserSince(userID, operation, since)
+}
+
+func (s *LimitService) sumByAccount(tx *sql.Tx, accountID int, operation string, since time.Time) (float64, error) {
+	if tx != nil {
+		return s.limitRepo.SumByAccountSinceTx(tx, accountID, operation, since)
+	}
+	return s.limitRepo.SumByAccountSince(accountID, operation, since)
+}
+
+func newLimitStatus(accountID *int, operation string, l Limits, daily, monthly float64) *model.LimitStatus {
+	return &model.LimitStatus{
+		AccountID:        accountID,
+		Operation:        operation,
+		PerOperationMax:  l.PerOperationMax,
+		DailyLimit:       l.Daily,
+		DailyUsed:        daily,
+		DailyRemaining:   math.Max(0, l.Daily-daily),
+		MonthlyLimit:     l.Monthly,
+		MonthlyUsed:      monthly,
+		MonthlyRemaining: math.Max(0, l.Monthly-monthly),
+	}
+}
+
+// merge накладывает заданные поля настройки на базовые лимиты.
+func merge(base Limits, l *model.TransactionLimit) Limits {
+	if l.DailyLimit != nil {
+		base.Daily = *l.DailyLimit
+	}
+	if l.MonthlyLimit != nil {
+		base.Monthly = *l.MonthlyLimit
+	}
+	if l.PerOperationMax != nil {
+		base.PerOperationMax = *l.PerOperationMax
+	}
+	return base
+}
+
+func exceeds(v *float64, max float64) bool {
+	return v != nil && *v > max
+}
@@ -0,0 +1,279 @@
+package service
+
+import (
+	"Bank/internal/model"
+	"Bank/internal/repository"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrLoginThrottled     = errors.New("too many failed login attempts, try again later")
+	ErrAccountLocked      = errors.New("account is temporarily locked after failed login attempts")
+	ErrInvalidUnlockToken = errors.New("invalid or expired unlock token")
+	// ErrTooManyVerifications — подряд слишком много неверных кодов второго
+	// фактора или паролей при повторной аутентификации.
+	ErrTooManyVerifications = errors.New("too many failed verification attempts, try again later")
+)
+
+// LoginDelayError — вход временно запрещён; RetryAfter — сколько ждать.
+type LoginDelayError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *LoginDelayError) Error() string { return e.Err.Error() }
+func (e *LoginDelayError) Unwrap() error { return e.Err }
+
+// throttlePolicy — правила для одного вида ключа. После freeAttempts неудач
+// каждая следующая попытка ждёт вдвое дольше (1 с, 2 с, 4 с… до maxLoginDelay),
+// после maxAttempts ключ блокируется на lockout.
+type throttlePolicy struct {
+	freeAttempts int
+	maxAttempts  int
+	window       time.Duration // пауза, после которой счёт начинается заново
+	lockout      time.Duration
+}
+
+var (
+	accountThrottle = throttlePolicy{freeAttempts: 3, maxAttempts: 10, window: time.Hour, lockout: 30 * time.Minute}
+	// с одного адреса могут входить несколько клиентов (NAT), поэтому порог выше
+	ipThrottle = throttlePolicy{freeAttempts: 20, maxAttempts: 100, window: time.Hour, lockout: time.Hour}
+	// проверки кода и пароля в уже открытой сессии (переводы, step-up):
+	// с украденным токеном доступа их нельзя подбирать перебором
+	verifyThrottle = throttlePolicy{freeAttempts: 3, maxAttempts: 10, window: time.Hour, lockout: time.Hour}
+)
+
+const (
+	maxLoginDelay = 5 * time.Minute
+	// throttleRetention — сколько хранить счётчики без новых неудач.
+	throttleRetention = 24 * time.Hour
+)
+
+func (p throttlePolicy) delay(failures int) time.Duration {
+	n := failures - p.freeAttempts
+	if n <= 0 {
+		return 0
+	}
+	if n > 10 {
+		return maxLoginDelay
+	}
+	return min(time.Second<<(n-1), maxLoginDelay)
+}
+
+// LoginThrottleService ограничивает подбор пароля: считает неудачные входы
+// по email и по IP, задерживает повторные попытки и временно блокирует вход.
+type LoginThrottleService struct {
+	throttleRepo repository.LoginThrottleRepository
+	userRepo     repository.UserRepository
+	mailSvc      MailService
+	signKey      []byte
+	baseURL      string
+}
+
+func NewLoginThrottleService(
+	tr repository.LoginThrottleRepository,
+	ur repository.UserRepository,
+	mailSvc MailService,
+	signKey, baseURL string,
+) *LoginThrottleService {
+	return &LoginThrottleService{
+		throttleRepo: tr,
+		userRepo:     ur,
+		mailSvc:      mailSvc,
+		signKey:      []byte(signKey),
+		baseURL:      strings.TrimRight(baseURL, "/"),
+	}
+}
+
+// Check вызывается до проверки пароля и возвращает *LoginDelayError, если
+// попытку нужно отклонить. Для неизвестного email правила те же, чтобы по
+// ответу нельзя было узнать, зарегистрирован ли адрес.
+func (s *LoginThrottleService) Check(email, ip string) error {
+	if err := s.check(emailKey(email), accountThrottle, ErrAccountLocked, ErrLoginThrottled); err != nil {
+		return err
+	}
+	return s.check(ipKey(ip), ipThrottle, ErrLoginThrottled, ErrLoginThrottled)
+}
+
+func (s *LoginThrottleService) check(key string, p throttlePolicy, lockedErr, delayErr error) error {
+	t, err := s.throttleRepo.Get(key)
+	if errors.Is(err, repository.ErrLoginThrottleNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if t.LockedUntil != nil && now.Before(*t.LockedUntil) {
+		return &LoginDelayError{Err: lockedErr, RetryAfter: t.LockedUntil.Sub(now)}
+	}
+	if t.LockedUntil != nil || now.Sub(t.LastFailureAt) > p.window {
+		return nil
+	}
+	if next := t.LastFailureAt.Add(p.delay(t.Failures)); now.Before(next) {
+		return &LoginDelayError{Err: delayErr, RetryAfter: next.Sub(now)}
+	}
+	return nil
+}
+
+// Failed учитывает неудачную попытку; u — пользователь с этим email, если он есть.
+func (s *LoginThrottleService) Failed(email, ip string, u *model.User) error {
+	locked, err := s.recordFailure(emailKey(email), accountThrottle)
+	if err != nil {
+		return err
+	}
+	if locked != nil && u != nil {
+		log.Printf("Вход пользователя #%d заблокирован до %s после неудачных попыток", u.ID, locked.Format(time.RFC3339))
+		if err := s.sendLockout(u, *locked); err != nil {
+			log.Printf("Письмо о блокировке входа пользователя #%d: %v", u.ID, err)
+		}
+	}
+	locked, err = s.recordFailure(ipKey(ip), ipThrottle)
+	if err != nil {
+		return err
+	}
+	if locked != nil {
+		log.Printf("Вход с адреса %s заблокирован до %s", ip, locked.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// recordFailure возвращает время окончания блокировки, если она наступила сейчас.
+func (s *LoginThrottleService) recordFailure(key string, p throttlePolicy) (*time.Time, error) {
+	t, err := s.throttleRepo.RecordFailure(key, p.window)
+	if err != nil {
+		return nil, err
+	}
+	if t.Failures < p.maxAttempts {
+		return nil, nil
+	}
+	until := time.Now().Add(p.lockout)
+	ok, err := s.throttleRepo.Lock(key, until)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &until, nil
+}
+
+// Succeeded сбрасывает счётчик аккаунта. Счётчик IP не сбрасывается:
+// иначе перебор можно было бы перемежать входом в собственный аккаунт.
+func (s *LoginThrottleService) Succeeded(email string) error {
+	return s.throttleRepo.Reset(emailKey(email))
+}
+
+// Unlock снимает блокировку по ссылке из письма. Ссылка привязана к
+// конкретной блокировке и после снятия или истечения перестаёт действовать.
+func (s *LoginThrottleService) Unlock(token string) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return ErrInvalidUnlockToken
+	}
+	userID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return ErrInvalidUnlockToken
+	}
+	until, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || time.Now().Unix() >= until {
+		return ErrInvalidUnlockToken
+	}
+	u, err := s.userRepo.GetByID(userID)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return ErrInvalidUnlockToken
+	}
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(s.sign(parts[0]+"."+parts[1], u.Email))) {
+		return ErrInvalidUnlockToken
+	}
+
+	t, err := s.throttleRepo.Get(emailKey(u.Email))
+	if errors.Is(err, repository.ErrLoginThrottleNotFound) {
+		return ErrInvalidUnlockToken
+	}
+	if err != nil {
+		return err
+	}
+	if t.LockedUntil == nil || t.LockedUntil.Unix() != until {
+		return ErrInvalidUnlockToken
+	}
+	if err := s.throttleRepo.Reset(t.Key); err != nil {
+		return err
+	}
+	log.Printf("Вход пользователя #%d разблокирован по ссылке из письма", u.ID)
+	return nil
+}
+
+// CheckVerification вызывается перед проверкой кода второго фактора или
+// пароля пользователя с открытой сессией; *LoginDelayError — попытку отклонить.
+func (s *LoginThrottleService) CheckVerification(userID int) error {
+	return s.check(verifyKey(userID), verifyThrottle, ErrTooManyVerifications, ErrTooManyVerifications)
+}
+
+// VerificationFailed учитывает неверный код или пароль.
+func (s *LoginThrottleService) VerificationFailed(userID int) error {
+	locked, err := s.recordFailure(verifyKey(userID), verifyThrottle)
+	if err != nil {
+		return err
+	}
+	if locked != nil {
+		log.Printf("Проверки кода и пароля пользователя #%d заблокированы до %s", userID, locked.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// VerificationSucceeded сбрасывает счётчик после верного кода.
+func (s *LoginThrottleService) VerificationSucceeded(userID int) error {
+	return s.throttleRepo.Reset(verifyKey(userID))
+}
+
+// Purge удаляет старые счётчики; вызывается шедулером.
+func (s *LoginThrottleService) Purge() error {
+	n, err := s.throttleRepo.DeleteStale(time.Now().Add(-throttleRetention))
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		log.Printf("Удалено счётчиков неудачных входов: %d", n)
+	}
+	return nil
+}
+
+func (s *LoginThrottleService) sendLockout(u *model.User, until time.Time) error {
+	payload := fmt.Sprintf("%d.%d", u.ID, until.Unix())
+	link := s.baseURL + "/login/unlock?token=" + payload + "." + s.sign(payload, u.Email)
+	body := fmt.Sprintf(
+		"<h1>Вход временно заблокирован</h1>"+
+			"<p>Зафиксировано несколько неудачных попыток входа в ваш аккаунт, вход заблокирован до %s.</p>"+
+			"<p>Если это были вы, снимите блокировку по ссылке: <a href=\"%s\">%s</a></p>"+
+			"<p>Если нет — рекомендуем сменить пароль и подключить второй фактор.</p>",
+		until.Format("02.01.2006 15:04 MST"), link, link,
+	)
+	return s.mailSvc.Send(u.Email, "Вход в аккаунт заблокирован", body)
+}
+
+func (s *LoginThrottleService) sign(payload, email string) string {
+	h := hmac.New(sha256.New, s.signKey)
+	h.Write([]byte("login-unlock:" + payload + ":" + email))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+func emailKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+func verifyKey(userID int) string {
+	return "verify:" + strconv.Itoa(userID)
+}
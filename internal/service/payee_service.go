@@ -0,0 +1,136 @@
+package service
+
+import (
+	"Bank/internal/model"
+	"Bank/internal/repository"
+	"errors"
+)
+
+var (
+	ErrPayeeNotYours  = errors.New("payee does not belong to user")
+	ErrAmountRequired = errors.New("amount is required: payee has no default amount")
+)
+
+type PayeeService struct {
+	payeeRepo    repository.PayeeRepository
+	accountRepo  repository.AccountRepository
+	recipientSvc *RecipientService
+	accSvc       *AccountService
+}
+
+func NewPayeeService(
+	pr repository.PayeeRepository,
+	ar repository.AccountRepository,
+	recipientSvc *RecipientService,
+	accSvc *AccountService,
+) *PayeeService {
+	return &PayeeService{
+		payeeRepo:    pr,
+		accountRepo:  ar,
+		recipientSvc: recipientSvc,
+		accSvc:       accSvc,
+	}
+}
+
+func (s *PayeeService) Create(userID int, req *model.PayeeCreate) (*model.Payee, error) {
+	p := &model.Payee{UserID: userID}
+	if err := s.fill(p, req); err != nil {
+		return nil, err
+	}
+	if err := s.payeeRepo.Create(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (s *PayeeService) List(userID int) ([]*model.Payee, error) {
+	return s.payeeRepo.ListByUser(userID)
+}
+
+func (s *PayeeService) Get(userID, payeeID int) (*model.Payee, error) {
+	p, err := s.payeeRepo.GetByID(payeeID)
+	if err != nil {
+		return nil, err
+	}
+	if p.UserID != userID {
+		return nil, ErrPayeeNotYours
+	}
+	return p, nil
+}
+
+func (s *PayeeService) Update(userID, payeeID int, req *model.PayeeCreate) (*model.Payee, error) {
+	p, err := s.Get(userID, payeeID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.fill(p, req); err != nil {
+		return nil, err
+	}
+	if err := s.payeeRepo.Update(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (s *PayeeService) Delete(userID, payeeID int) error {
+	if _, err := s.Get(userID, payeeID); err != nil {
+		return err
+	}
+	return s.payeeRepo.Delete(userID, payeeID)
+}
+
+// Transfer переводит деньги сохранённому получателю. Если amount равен нулю,
+// используется сумма по умолчанию из карточки получателя.
+func (s *PayeeService) Transfer(userID, payeeID, fromID int, amount float64, otp string) (*model.TransferResult, error) {
+	p, err := s.Get(userID, payeeID)
+	if err != nil {
+		return nil, err
+	}
+	if amount == 0 {
+		if p.DefaultAmount == nil {
+			return nil, ErrAmountRequired
+		}
+		amount = *p.DefaultAmount
+	}
+
+	toID, err := s.targetAccount(p)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.accSvc.AuthorizeTransfer(userID, amount, otp); err != nil {
+		return nil, err
+	}
+	return s.accSvc.TransferWithNote(userID, fromID, toID, amount, p.Description)
+}
+
+// fill переносит поля запроса в карточку, проверяя, что получатель существует.
+func (s *PayeeService) fill(p *model.Payee, req *model.PayeeCreate) error {
+	p.Nickname = req.Nickname
+	p.DefaultAmount = req.DefaultAmount
+	p.Description = req.Description
+	p.ToAccountID = nil
+	p.RecipientType, p.Recipient = "", ""
+
+	if req.ToAccountID != 0 {
+		toID := req.ToAccountID
+		p.ToAccountID = &toID
+	} else {
+		p.RecipientType, p.Recipient = req.RecipientType, req.Recipient
+	}
+	_, err := s.targetAccount(p)
+	return err
+}
+
+func (s *PayeeService) targetAccount(p *model.Payee) (int, error) {
+	if p.ToAccountID != nil {
+		if _, err := s.accountRepo.GetByID(*p.ToAccountID); err != nil {
+			return 0, err
+		}
+		return *p.ToAccountID, nil
+	}
+	rcpt, err := s.recipientSvc.Resolve(p.RecipientType, p.Recipient)
+	if err != nil {
+		return 0, err
+	}
+	return rcpt.AccountID, nil
+}
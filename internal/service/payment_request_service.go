@@ -0,0 +1,223 @@
+package service
+
+import (
+	"Bank/internal/model"
+	"Bank/internal/repository"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultPaymentRequestTTL = 72 * time.Hour
+
+var (
+	ErrInvalidPaymentToken    = errors.New("invalid or expired payment link")
+	ErrPaymentRequestNotYours = errors.New("payment request does not belong to user")
+)
+
+type PaymentRequestService struct {
+	requestRepo repository.PaymentRequestRepository
+	accountRepo repository.AccountRepository
+	userRepo    repository.UserRepository
+	accSvc      *AccountService
+	signKey     []byte
+	baseURL     string
+}
+
+func NewPaymentRequestService(
+	pr repository.PaymentRequestRepository,
+	ar repository.AccountRepository,
+	ur repository.UserRepository,
+	accSvc *AccountService,
+	signKey, baseURL string,
+) *PaymentRequestService {
+	return &PaymentRequestService{
+		requestRepo: pr,
+		accountRepo: ar,
+		userRepo:    ur,
+		accSvc:      accSvc,
+		signKey:     []byte(signKey),
+		baseURL:     strings.TrimRight(baseURL, "/"),
+	}
+}
+
+func (s *PaymentRequestService) Create(userID int, req *model.PaymentRequestCreate) (*model.PaymentLink, error) {
+	acc, err := s.accountRepo.GetByID(req.ToAccountID)
+	if err != nil {
+		return nil, err
+	}
+	if acc.UserID != userID {
+		return nil, ErrAccessDenied
+	}
+
+	ttl := defaultPaymentRequestTTL
+	if req.ExpiresInHours > 0 {
+		ttl = time.Duration(req.ExpiresInHours) * time.Hour
+	}
+	p := &model.PaymentRequest{
+		UserID:      userID,
+		ToAccountID: req.ToAccountID,
+		Amount:      req.Amount,
+		Description: req.Description,
+		Status:      model.PaymentRequestPending,
+		ExpiresAt:   time.Now().Add(ttl),
+	}
+	if err := s.requestRepo.Create(p); err != nil {
+		return nil, err
+	}
+	return s.link(p), nil
+}
+
+func (s *PaymentRequestService) List(userID int) ([]*model.PaymentRequest, error) {
+	list, err := s.requestRepo.ListByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range list {
+		markExpired(p)
+	}
+	return list, nil
+}
+
+// Link заново выдаёт ссылку на собственный запрос, например для повторной отправки.
+func (s *PaymentRequestService) Link(userID, requestID int) (*model.PaymentLink, error) {
+	p, err := s.requestRepo.GetByID(requestID)
+	if err != nil {
+		return nil, err
+	}
+	if p.UserID != userID {
+		return nil, ErrPaymentRequestNotYours
+	}
+	markExpired(p)
+	return s.link(p), nil
+}
+
+func (s *PaymentRequestService) Cancel(userID, requestID int) error {
+	p, err := s.requestRepo.GetByID(requestID)
+	if err != nil {
+		return err
+	}
+	if p.UserID != userID {
+		return ErrPaymentRequestNotYours
+	}
+	return s.requestRepo.Cancel(userID, requestID)
+}
+
+// View показывает плательщику запрос по токену из ссылки.
+func (s *PaymentRequestService) View(token string) (*model.PaymentRequestView, error) {
+	p, err := s.byToken(token)
+	if err != nil {
+		return nil, err
+	}
+	name := ""
+	if u, err := s.userRepo.GetByID(p.UserID); err == nil {
+		name = maskName(u.Username)
+	}
+	return &model.PaymentRequestView{
+		ID:            p.ID,
+		RecipientName: name,
+		Amount:        p.Amount,
+		Description:   p.Description,
+		Status:        p.Status,
+		ExpiresAt:     p.ExpiresAt,
+	}, nil
+}
+
+// Pay оплачивает запрос одним вызовом и связывает его с проводками перевода.
+func (s *PaymentRequestService) Pay(userID int, token string, fromAccountID int, otp string) (*model.PaymentRequest, error) {
+	p, err := s.byToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.accSvc.AuthorizeTransfer(userID, p.Amount, otp); err != nil {
+		return nil, err
+	}
+	if err := s.requestRepo.Claim(p.ID); err != nil {
+		return nil, err
+	}
+
+	note := "payreq:" + strconv.Itoa(p.ID)
+	if p.Description != "" {
+		note += " " + p.Description
+	}
+	res, err := s.accSvc.TransferWithNote(userID, fromAccountID, p.ToAccountID, p.Amount, note)
+	if err != nil {
+		_ = s.requestRepo.Release(p.ID)
+		return nil, err
+	}
+
+	p.PayerUserID = &userID
+	p.DebitTransactionID = &res.Debit.ID
+	p.CreditTransactionID = &res.Credit.ID
+	if err := s.requestRepo.MarkPaid(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (s *PaymentRequestService) byToken(token string) (*model.PaymentRequest, error) {
+	id, err := s.verifyToken(token)
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.requestRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	markExpired(p)
+	return p, nil
+}
+
+func (s *PaymentRequestService) link(p *model.PaymentRequest) *model.PaymentLink {
+	token := s.signToken(p.ID, p.ExpiresAt)
+	url := s.baseURL + "/payment-links/" + token
+	return &model.PaymentLink{
+		Request:   p,
+		Token:     token,
+		URL:       url,
+		QRPayload: url,
+	}
+}
+
+// signToken формирует токен вида "<id>.<expires_unix>.<hmac>".
+func (s *PaymentRequestService) signToken(id int, expiresAt time.Time) string {
+	payload := fmt.Sprintf("%d.%d", id, expiresAt.Unix())
+	return payload + "." + s.sign(payload)
+}
+
+func (s *PaymentRequestService) verifyToken(token string) (int, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return 0, ErrInvalidPaymentToken
+	}
+	payload := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(s.sign(payload))) {
+		return 0, ErrInvalidPaymentToken
+	}
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, ErrInvalidPaymentToken
+	}
+	exp, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || time.Now().Unix() > exp {
+		return 0, ErrInvalidPaymentToken
+	}
+	return id, nil
+}
+
+func (s *PaymentRequestService) sign(payload string) string {
+	h := hmac.New(sha256.New, s.signKey)
+	h.Write([]byte("payment-request:" + payload))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+func markExpired(p *model.PaymentRequest) {
+	if p.Status == model.PaymentRequestPending && time.Now().After(p.ExpiresAt) {
+		p.Status = model.PaymentRequestExpired
+	}
+}
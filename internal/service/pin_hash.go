@@ -0,0 +1,77 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Параметры argon2id для PIN. Пространство PIN мало, поэтому стойкость
+// держится на стоимости derivation и счётчике попыток.
+const (
+	pinArgonTime    = 3
+	pinArgonMemory  = 64 * 1024
+	pinArgonThreads = 2
+	pinArgonKeyLen  = 32
+	pinSaltLen      = 16
+)
+
+var errMalformedPINHash = errors.New("malformed pin hash")
+
+// hashPIN возвращает argon2id-хеш PIN в формате PHC.
+func hashPIN(pin string) (string, error) {
+	salt := make([]byte, pinSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(pin), salt, pinArgonTime, pinArgonMemory, pinArgonThreads, pinArgonKeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, pinArgonMemory, pinArgonTime, pinArgonThreads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// checkPIN сверяет PIN с хешем, используя параметры из самого хеша.
+func checkPIN(encoded, pin string) (bool, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return false, errMalformedPINHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false, errMalformedPINHash
+	}
+	var memory, time uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &threads); err != nil {
+		return false, errMalformedPINHash
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, errMalformedPINHash
+	}
+	want, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return false, errMalformedPINHash
+	}
+	got := argon2.IDKey([]byte(pin), salt, time, memory, threads, uint32(len(want)))
+	return subtle.ConstantTimeCompare(got, want) == 1, nil
+}
+
+// weakPIN отсекает PIN из одинаковых цифр и возрастающие/убывающие последовательности.
+func weakPIN(pin string) bool {
+	same, up, down := true, true, true
+	for i := 1; i < len(pin); i++ {
+		d := int(pin[i]) - int(pin[i-1])
+		same = same && d == 0
+		up = up && d == 1
+		down = down && d == -1
+	}
+	return same || up || down
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"Bank/internal/model"
+	"Bank/internal/repository"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ST0001 — идентификатор формата, 2 — кодировка UTF-8.
+	qrHeader = "ST00012"
+	qrSep    = "|"
+
+	// Номер счёта физлица в рублях: 40817 810 + 12 цифр внутреннего id.
+	accountNumberPrefix = "40817810"
+)
+
+var (
+	ErrInvalidQRPayload = errors.New("invalid ST00012 payload")
+	ErrInvalidAmount    = errors.New("invalid amount")
+)
+
+type QRService struct {
+	accountRepo repository.AccountRepository
+	userRepo    repository.UserRepository
+	bankName    string
+	bic         string
+	corrAcc     string
+}
+
+func NewQRService(
+	ar repository.AccountRepository,
+	ur repository.UserRepository,
+	bankName, bic, corrAcc string,
+) *QRService {
+	return &QRService{
+		accountRepo: ar,
+		userRepo:    ur,
+		bankName:    bankName,
+		bic:         bic,
+		corrAcc:     corrAcc,
+	}
+}
+
+// Generate строит payload для зачисления на счёт пользователя.
+// amount и purpose необязательны: без суммы плательщик введёт её сам.
+func (s *QRService) Generate(userID, accountID int, amount float64, purpose string) (*model.QRPayload, error) {
+	acc, err := s.accountRepo.GetByID(accountID)
+	if err != nil {
+		return nil, err
+	}
+	if acc.UserID != userID {
+		return nil, ErrAccessDenied
+	}
+	if amount < 0 || math.IsNaN(amount) || math.IsInf(amount, 0) {
+		return nil, ErrInvalidAmount
+	}
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := []string{
+		qrHeader,
+		"Name=" + qrValue(user.Username),
+		"PersonalAcc=" + AccountNumber(accountID),
+		"BankName=" + qrValue(s.bankName),
+		"BIC=" + s.bic,
+		"CorrespAcc=" + s.corrAcc,
+	}
+	if amount > 0 {
+		fields = append(fields, "Sum="+strconv.FormatInt(int64(math.Round(amount*100)), 10))
+	}
+	if purpose != "" {
+		fields = append(fields, "Purpose="+qrValue(purpose))
+	}
+	return &model.QRPayload{Payload: strings.Join(fields, qrSep)}, nil
+}
+
+// Parse разбирает payload и возвращает черновик перевода.
+func (s *QRService) Parse(payload string) (*model.TransferDraft, error) {
+	payload = strings.TrimSpace(payload)
+	if len(payload) < len(qrHeader)+1 || !strings.HasPrefix(payload, "ST0001") {
+		return nil, fmt.Errorf("%w: missing ST0001 header", ErrInvalidQRPayload)
+	}
+	if payload[6] != '2' {
+		return nil, fmt.Errorf("%w: only UTF-8 encoding is supported", ErrInvalidQRPayload)
+	}
+	sep := string(payload[7])
+
+	fields := make(map[string]string)
+	for _, part := range strings.Split(payload[8:], sep) {
+		if part == "" {
+			continue
+		}
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("%w: malformed field %q", ErrInvalidQRPayload, part)
+		}
+		fields[kv[0]] = strings.TrimSpace(kv[1])
+	}
+
+	for _, key := range []string{"Name", "PersonalAcc", "BankName", "BIC", "CorrespAcc"} {
+		if fields[key] == "" {
+			return nil, fmt.Errorf("%w: required field %s is missing", ErrInvalidQRPayload, key)
+		}
+	}
+	if !isDigits(fields["PersonalAcc"], 20) {
+		return nil, fmt.Errorf("%w: PersonalAcc must be 20 digits", ErrInvalidQRPayload)
+	}
+	if !isDigits(fields["BIC"], 9) {
+		return nil, fmt.Errorf("%w: BIC must be 9 digits", ErrInvalidQRPayload)
+	}
+	if fields["CorrespAcc"] != "0" && !isDigits(fields["CorrespAcc"], 20) {
+		return nil, fmt.Errorf("%w: CorrespAcc must be 20 digits or 0", ErrInvalidQRPayload)
+	}
+
+	draft := &model.TransferDraft{
+		Name:        fields["Name"],
+		PersonalAcc: fields["PersonalAcc"],
+		BankName:    fields["BankName"],
+		BIC:         fields["BIC"],
+		CorrespAcc:  fields["CorrespAcc"],
+		Purpose:     fields["Purpose"],
+		PayeeINN:    fields["PayeeINN"],
+		KPP:         fields["KPP"],
+	}
+	if sum, ok := fields["Sum"]; ok {
+		kopecks, err := strconv.ParseInt(sum, 10, 64)
+		if err != nil || kopecks <= 0 || len(sum) > 18 {
+			return nil, fmt.Errorf("%w: Sum must be a positive amount in kopecks", ErrInvalidQRPayload)
+		}
+		draft.Amount = float64(kopecks) / 100
+	}
+
+	if draft.BIC == s.bic {
+		id, ok := accountIDFromNumber(draft.PersonalAcc)
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown account of this bank", ErrInvalidQRPayload)
+		}
+		if _, err := s.accountRepo.GetByID(id); err != nil {
+			return nil, err
+		}
+		draft.Internal = true
+		draft.ToAccountID = &id
+	}
+	return draft, nil
+}
+
+// AccountNumber возвращает 20-значный номер счёта для внутреннего id.
+func AccountNumber(accountID int) string {
+	return fmt.Sprintf("%s%012d", accountNumberPrefix, accountID)
+}
+
+func accountIDFromNumber(number string) (int, bool) {
+	if !strings.HasPrefix(number, accountNumberPrefix) {
+		return 0, false
+	}
+	id, err := strconv.Atoi(strings.TrimLeft(number[len(accountNumberPrefix):], "0"))
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return id, true
+}
+
+// qrValue убирает из значения символ-разделитель.
+func qrValue(v string) string {
+	return strings.ReplaceAll(strings.TrimSpace(v), qrSep, " ")
+}
+
+func isDigits(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
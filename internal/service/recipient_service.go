@@ -0,0 +1,327 @@
+package service
+
+import (
+	"Bank/internal/model"
+	"Bank/internal/repository"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const confirmationTTL = 5 * time.Minute
+
+const (
+	phoneCodeTTL         = 10 * time.Minute
+	phoneCodeResendAfter = time.Minute
+	phoneCodeMaxAttempts = 5
+)
+
+var (
+	ErrRecipientNotFound = errors.New("recipient not found")
+	ErrInvalidPhone      = errors.New("invalid phone number")
+)
+
+// Recipient — получатель перевода, найденный по псевдониму.
+type Recipient struct {
+	UserID     int
+	AccountID  int
+	MaskedName string
+}
+
+type RecipientService struct {
+	userRepo    repository.UserRepository
+	accountRepo repository.AccountRepository
+	aliasRepo   repository.PhoneAliasRepository
+	confirmRepo repository.TransferConfirmationRepository
+	accSvc      *AccountService
+	smsSvc      SMSService
+}
+
+func NewRecipientService(
+	ur repository.UserRepository,
+	ar repository.AccountRepository,
+	pr repository.PhoneAliasRepository,
+	cr repository.TransferConfirmationRepository,
+	accSvc *AccountService,
+	smsSvc SMSService,
+) *RecipientService {
+	return &RecipientService{
+		userRepo:    ur,
+		accountRepo: ar,
+		aliasRepo:   pr,
+		confirmRepo: cr,
+		accSvc:      accSvc,
+		smsSvc:      smsSvc,
+	}
+}
+
+// RegisterPhone привязывает номер к счёту. Новый номер начинает принимать
+// переводы только после ConfirmPhone с кодом из SMS; у подтверждённого
+// номера владельца меняется лишь счёт зачисления.
+func (s *RecipientService) RegisterPhone(userID int, req *model.PhoneAliasCreate) (*model.PhoneAlias, error) {
+	phone, err := normalizePhone(req.Phone)
+	if err != nil {
+		return nil, err
+	}
+	acc, err := s.accountRepo.GetByID(req.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	if acc.UserID != userID {
+		return nil, ErrAccessDenied
+	}
+
+	existing, err := s.aliasRepo.GetByPhone(phone)
+	switch {
+	case errors.Is(err, repository.ErrAliasNotFound):
+	case err != nil:
+		return nil, err
+	case existing.VerifiedAt != nil:
+		if existing.UserID != userID {
+			return nil, repository.ErrAliasTaken
+		}
+		if err := s.aliasRepo.UpdateAccount(userID, phone, req.AccountID); err != nil {
+			return nil, err
+		}
+		existing.AccountID = req.AccountID
+		return existing, nil
+	case existing.UserID != userID &&
+		existing.CodeExpiresAt != nil && time.Now().Before(*existing.CodeExpiresAt):
+		// чужое подтверждение ещё идёт; номер освободится, когда истечёт код
+		return nil, repository.ErrAliasTaken
+	case existing.CodeExpiresAt != nil &&
+		time.Now().Before(existing.CodeExpiresAt.Add(phoneCodeResendAfter-phoneCodeTTL)):
+		// не чаще раза в минуту на номер, кто бы ни запрашивал код
+		return nil, ErrTooManyAttempts
+	}
+
+	code, err := randomDigits(6)
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().Add(phoneCodeTTL)
+	alias := &model.PhoneAlias{
+		Phone:         phone,
+		UserID:        userID,
+		AccountID:     req.AccountID,
+		CodeHash:      hashSecret(code),
+		CodeExpiresAt: &expiresAt,
+	}
+	if err := s.aliasRepo.Claim(alias); err != nil {
+		return nil, err
+	}
+	text := fmt.Sprintf("Код подтверждения номера для переводов: %s. Никому его не сообщайте.", code)
+	if err := s.smsSvc.Send(phone, text); err != nil {
+		log.Printf("SMS с кодом подтверждения номера для пользователя #%d: %v", userID, err)
+		return nil, err
+	}
+	return alias, nil
+}
+
+// ConfirmPhone активирует номер кодом из SMS. Попытки ввода ограничены,
+// после их исчерпания нужно запросить новый код.
+func (s *RecipientService) ConfirmPhone(userID int, req *model.PhoneAliasConfirm) (*model.PhoneAlias, error) {
+	phone, err := normalizePhone(req.Phone)
+	if err != nil {
+		return nil, err
+	}
+	codeHash, err := s.aliasRepo.TakeCodeAttempt(userID, phone, phoneCodeMaxAttempts)
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(codeHash), []byte(hashSecret(req.Code))) != 1 {
+		return nil, repository.ErrAliasCodeInvalid
+	}
+	if err := s.aliasRepo.Verify(userID, phone); err != nil {
+		return nil, err
+	}
+	log.Printf("Пользователь #%d подтвердил номер для переводов", userID)
+	return s.aliasRepo.GetByPhone(phone)
+}
+
+func (s *RecipientService) ListPhones(userID int) ([]*model.PhoneAlias, error) {
+	return s.aliasRepo.ListByUser(userID)
+}
+
+func (s *RecipientService) DeletePhone(userID int, phone string) error {
+	normalized, err := normalizePhone(phone)
+	if err != nil {
+		return err
+	}
+	return s.aliasRepo.Delete(userID, normalized)
+}
+
+// Resolve находит счёт зачисления по имени пользователя, email или телефону.
+// Для телефона используется счёт, выбранный при регистрации номера
+// (только подтверждённого),
+// для username и email — самый первый счёт пользователя.
+func (s *RecipientService) Resolve(kind, value string) (*Recipient, error) {
+	var user *model.User
+	accountID := 0
+
+	switch kind {
+	case model.RecipientPhone:
+		phone, err := normalizePhone(value)
+		if err != nil {
+			return nil, err
+		}
+		alias, err := s.aliasRepo.GetVerified(phone)
+		if errors.Is(err, repository.ErrAliasNotFound) {
+			return nil, ErrRecipientNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		if user, err = s.userRepo.GetByID(alias.UserID); err != nil {
+			return nil, err
+		}
+		accountID = alias.AccountID
+	case model.RecipientEmail, model.RecipientUsername:
+		var err error
+		if kind == model.RecipientEmail {
+			user, err = s.userRepo.GetByEmail(strings.TrimSpace(value))
+		} else {
+			user, err = s.userRepo.GetByUsername(strings.TrimSpace(value))
+		}
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil, ErrRecipientNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		accounts, err := s.accountRepo.ListByUser(user.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, acc := range accounts {
+			if accountID == 0 || acc.ID < accountID {
+				accountID = acc.ID
+			}
+		}
+		if accountID == 0 {
+			return nil, ErrRecipientNotFound
+		}
+	default:
+		return nil, ErrRecipientNotFound
+	}
+
+	return &Recipient{
+		UserID:     user.ID,
+		AccountID:  accountID,
+		MaskedName: maskName(user.Username),
+	}, nil
+}
+
+// Prepare находит получателя и создаёт подтверждение перевода.
+// Деньги не списываются, пока клиент не вызовет Confirm.
+func (s *RecipientService) Prepare(userID int, req *model.RecipientTransferCreate) (*model.TransferConfirmation, error) {
+	acc, err := s.accountRepo.GetByID(req.FromAccountID)
+	if err != nil {
+		return nil, err
+	}
+	if acc.UserID != userID {
+		return nil, ErrAccessDenied
+	}
+
+	rcpt, err := s.Resolve(req.RecipientType, req.Recipient)
+	if err != nil {
+		return nil, err
+	}
+	if rcpt.AccountID == req.FromAccountID {
+		return nil, ErrSameAccount
+	}
+	// второй фактор проверяется при подготовке: подтверждение — лишь
+	// согласие с найденным получателем
+	if err := s.accSvc.AuthorizeTransfer(userID, req.Amount, req.OTP); err != nil {
+		return nil, err
+	}
+
+	token, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	c := &model.TransferConfirmation{
+		Token:         token,
+		UserID:        userID,
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   rcpt.AccountID,
+		Amount:        req.Amount,
+		RecipientName: rcpt.MaskedName,
+		ExpiresAt:     time.Now().Add(confirmationTTL),
+	}
+	if err := s.confirmRepo.Create(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Confirm исполняет ранее подготовленный перевод. Подтверждение
+// одноразовое: при ошибке перевода его нужно запросить заново.
+func (s *RecipientService) Confirm(userID int, token string) (*model.TransferResult, error) {
+	c, err := s.confirmRepo.Consume(userID, token)
+	if err != nil {
+		return nil, err
+	}
+	return s.accSvc.Transfer(userID, c.FromAccountID, c.ToAccountID, c.Amount)
+}
+
+// normalizePhone приводит номер к виду +7XXXXXXXXXX, отбрасывая
+// пробелы, скобки и дефисы; ведущая 8 трактуется как +7.
+func normalizePhone(raw string) (string, error) {
+	var b strings.Builder
+	for i, r := range strings.TrimSpace(raw) {
+		switch {
+		case unicode.IsDigit(r):
+			b.WriteRune(r)
+		case r == '+' && i == 0:
+		case r == ' ' || r == '-' || r == '(' || r == ')':
+		default:
+			return "", ErrInvalidPhone
+		}
+	}
+	digits := b.String()
+	if len(digits) == 11 && digits[0] == '8' {
+		digits = "7" + digits[1:]
+	}
+	if len(digits) < 10 || len(digits) > 15 {
+		return "", ErrInvalidPhone
+	}
+	return "+" + digits, nil
+}
+
+// maskName оставляет первую и последнюю букву имени: "ivanov" -> "i****v".
+func maskName(name string) string {
+	r := []rune(name)
+	if len(r) <= 2 {
+		return string(r[:1]) + "*"
+	}
+	return string(r[0]) + strings.Repeat("*", len(r)-2) + string(r[len(r)-1])
+}
+
+// randomDigits возвращает числовой код из n цифр.
+func randomDigits(n int) (string, error) {
+	b := make([]byte, n)
+	for i := range b {
+		d, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		b[i] = byte('0' + d.Int64())
+	}
+	return string(b), nil
+}
+
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"Bank/internal/model"
+	"Bank/internal/repository"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+)
+
+var ErrNotReversible = errors.New("transaction cannot be reversed")
+
+// ReversalService отменяет ошибочные операции компенсирующими проводками.
+// Исходные проводки не изменяются; компенсирующая ссылается на них через reversal_of.
+type ReversalService struct {
+	db          *sql.DB
+	userRepo    repository.UserRepository
+	accountRepo repository.AccountRepository
+	txRepo      repository.TransactionRepository
+	mailSvc     MailService
+}
+
+func NewReversalService(
+	db *sql.DB,
+	ur repository.UserRepository,
+	ar repository.AccountRepository,
+	tr repository.TransactionRepository,
+	mailSvc MailService,
+) *ReversalService {
+	return &ReversalService{
+		db:          db,
+		userRepo:    ur,
+		accountRepo: ar,
+		txRepo:      tr,
+		mailSvc:     mailSvc,
+	}
+}
+
+// Reverse отменяет операцию. Перевод отменяется целиком: компенсируются обе ноги.
+func (s *ReversalService) Reverse(operatorID, transactionID int, reason string) ([]*model.Transaction, error) {
+	orig, err := s.txRepo.GetByID(transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if orig.ReversalOf != nil {
+		return nil, ErrNotReversible
+	}
+
+	legs := []*model.Transaction{orig}
+	if orig.Type == "transfer" {
+		if orig.CounterpartID == nil {
+			return nil, ErrNotReversible
+		}
+		counterpart, err := s.txRepo.GetByID(*orig.CounterpartID)
+		if err != nil {
+			return nil, err
+		}
+		legs = append(legs, counterpart)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	var out []*model.Transaction
+	balances := make(map[int]float64)
+	for _, leg := range legs {
+		acc, err := s.accountRepo.GetByID(leg.AccountID)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+
+		t := &model.Transaction{
+			AccountID:   acc.ID,
+			Amount:      leg.Amount,
+			Description: withNote("reversal:"+strconv.Itoa(leg.ID), reason),
+			ReversalOf:  &leg.ID,
+		}
+		newBal := acc.Balance + leg.Amount
+		t.Type = model.TxReversalIn
+		t.Direction = model.TxCredit
+		if isCredit(leg) {
+			if acc.AvailableBalance < leg.Amount {
+				tx.Rollback()
+				return nil, ErrInsufficientFunds
+			}
+			newBal = acc.Balance - leg.Amount
+			t.Type = model.TxReversalOut
+			t.Direction = model.TxDebit
+		}
+
+		if err := s.accountRepo.UpdateBalance(tx, acc.ID, newBal); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if err := s.txRepo.CreateTx(tx, t); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		balances[acc.ID] = newBal
+		out = append(out, t)
+	}
+	if len(out) == 2 {
+		if err := s.txRepo.LinkCounterpartsTx(tx, out[0], out[1]); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	log.Printf("Оператор #%d отменил операцию #%d", operatorID, transactionID)
+
+	for _, t := range out {
+		s.notify(t, balances[t.AccountID])
+	}
+	return out, nil
+}
+
+func (s *ReversalService) notify(t *model.Transaction, balance float64) {
+	acc, err := s.accountRepo.GetByID(t.AccountID)
+	if err != nil {
+		return
+	}
+	user, err := s.userRepo.GetByID(acc.UserID)
+	if err != nil {
+		return
+	}
+	action := "возвращено на счёт"
+	if t.Type == model.TxReversalOut {
+		action = "списано со счёта"
+	}
+	body := fmt.Sprintf(
+		"<h1>Отмена операции</h1>"+
+			"<p>Операция #%d отменена банком: <strong>%.2f RUB</strong> %s #%d</p>"+
+			"<p>Ваш новый баланс: <strong>%.2f RUB</strong></p>",
+		*t.ReversalOf, t.Amount, action, t.AccountID, balance,
+	)
+	_ = s.mailSvc.Send(user.Email, "Операция отменена", body)
+}
+
+// isCredit сообщает, зачисляла ли проводка средства на счёт.
+func isCredit(t *model.Transaction) bool {
+	return t.Direction == model.TxCredit
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+var ErrSMSNotConfigured = errors.New("sms gateway is not configured")
+
+type SMSService interface {
+	Send(phone, text string) error
+}
+
+// smsGateway отправляет сообщения через HTTP-шлюз оператора:
+// POST {"phone": ..., "text": ...} с ключом в заголовке Authorization.
+type smsGateway struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// NewSMSService без адреса шлюза возвращает сервис, который отказывает
+// в отправке: коды подтверждения не должны теряться молча.
+func NewSMSService(url, token string) SMSService {
+	return &smsGateway{
+		url:    url,
+		token:  token,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (g *smsGateway) Send(phone, text string) error {
+	if g.url == "" {
+		return ErrSMSNotConfigured
+	}
+	body, err := json.Marshal(map[string]string{"phone": phone, "text": text})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", g.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+g.token)
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sms send failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("sms send failed: gateway status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"Bank/internal/model"
+	"Bank/internal/repository"
+	"errors"
+	"log"
+)
+
+// ErrOwnRole — администратор не может сменить роль самому себе,
+// чтобы банк не остался без администраторов.
+var ErrOwnRole = errors.New("cannot change own role")
+
+// StaffService — доступ сотрудников банка к данным любых клиентов.
+// Каждое обращение пишется в журнал аудита от имени сотрудника.
+type StaffService struct {
+	userRepo    repository.UserRepository
+	sessionRepo repository.SessionRepository
+	accSvc      *AccountService
+	creditSvc   *CreditService
+	cardSvc     *CardService
+	auditSvc    *AuditService
+}
+
+func NewStaffService(
+	ur repository.UserRepository,
+	sr repository.SessionRepository,
+	accSvc *AccountService,
+	creditSvc *CreditService,
+	cardSvc *CardService,
+	auditSvc *AuditService,
+) *StaffService {
+	return &StaffService{
+		userRepo:    ur,
+		sessionRepo: sr,
+		accSvc:      accSvc,
+		creditSvc:   creditSvc,
+		cardSvc:     cardSvc,
+		auditSvc:    auditSvc,
+	}
+}
+
+func (s *StaffService) UserAccounts(staffID, userID int, ip string) ([]*model.Account, error) {
+	if err := s.lookup(staffID, userID, model.AuditStaffViewAccounts, ip); err != nil {
+		return nil, err
+	}
+	return s.accSvc.GetUserAccounts(userID)
+}
+
+func (s *StaffService) UserCredits(staffID, userID int, ip string) ([]*model.Credit, error) {
+	if err := s.lookup(staffID, userID, model.AuditStaffViewCredits, ip); err != nil {
+		return nil, err
+	}
+	return s.creditSvc.ListCredits(userID)
+}
+
+func (s *StaffService) UserCards(staffID, userID int, ip string) ([]*model.CardResponse, error) {
+	if err := s.lookup(staffID, userID, model.AuditStaffViewCards, ip); err != nil {
+		return nil, err
+	}
+	return s.cardSvc.ListCards(userID)
+}
+
+// lookup записывает обращение в журнал до выдачи данных, в том числе
+// обращение к несуществующему клиенту.
+func (s *StaffService) lookup(staffID, userID int, action, ip string) error {
+	_, err := s.userRepo.GetByID(userID)
+	details := ""
+	if err != nil {
+		details = err.Error()
+	}
+	s.auditSvc.Record(staffID, action, userID, err == nil, ip, details)
+	return err
+}
+
+// SetRole меняет роль пользователя и завершает его сессии: роль зашита
+// в access-токены, и новые права должны действовать сразу.
+func (s *StaffService) SetRole(adminID, userID int, role, ip string) (*model.User, error) {
+	if adminID == userID {
+		return nil, ErrOwnRole
+	}
+	u, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	prev := u.Role
+	if prev == role {
+		return u, nil
+	}
+	if err := s.userRepo.SetRole(userID, role); err != nil {
+		return nil, err
+	}
+	u.Role = role
+	if _, err := s.sessionRepo.RevokeAllByUser(userID); err != nil {
+		return nil, err
+	}
+	s.auditSvc.Record(adminID, model.AuditRoleChange, userID, true, ip, prev+" -> "+role)
+	log.Printf("Роль пользователя #%d изменена: %s -> %s (администратор #%d)", userID, prev, role, adminID)
+	return u, nil
+}
+
+// BootstrapRoles назначает роль клиентам из списка при старте сервиса,
+// например первым администраторам. Сотрудников с другой ролью не трогает.
+func (s *StaffService) BootstrapRoles(userIDs []int, role string) {
+	for _, id := range userIDs {
+		ok, err := s.userRepo.PromoteCustomer(id, role)
+		if err != nil {
+			log.Printf("Назначение роли %s пользователю #%d: %v", role, id, err)
+			continue
+		}
+		if ok {
+			if _, err := s.sessionRepo.RevokeAllByUser(id); err != nil {
+				log.Printf("Отзыв сессий пользователя #%d: %v", id, err)
+			}
+			log.Printf("Пользователю #%d назначена роль %s", id, role)
+		}
+	}
+}
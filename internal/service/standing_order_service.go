@@ -0,0 +1,257 @@
+package service
+
+import (
+	"Bank/internal/model"
+	"Bank/internal/repository"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+)
+
+var (
+	ErrStandingOrderNotYours = errors.New("standing order does not belong to user")
+	ErrStandingOrderInactive = errors.New("standing order is not active")
+	ErrInvalidSchedule       = errors.New("invalid schedule: start date must not be in the past and end date must follow it")
+)
+
+// RetryPolicy задаёт, сколько раз и с каким интервалом повторять
+// исполнение поручения при нехватке средств или исчерпанном лимите.
+type RetryPolicy struct {
+	MaxAttempts int
+	Delay       time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 3, Delay: 24 * time.Hour}
+
+// standingOrderLease — на сколько захваченное поручение скрыто от других
+// запусков шедулера. Если запуск прервался, поручение вернётся в работу,
+// а уже проведённый платёж не повторится благодаря ключу исполнения.
+const standingOrderLease = 10 * time.Minute
+
+type StandingOrderService struct {
+	orderRepo   repository.StandingOrderRepository
+	accountRepo repository.AccountRepository
+	accSvc      *AccountService
+	retry       RetryPolicy
+}
+
+func NewStandingOrderService(
+	or repository.StandingOrderRepository,
+	ar repository.AccountRepository,
+	accSvc *AccountService,
+	retry RetryPolicy,
+) *StandingOrderService {
+	return &StandingOrderService{
+		orderRepo:   or,
+		accountRepo: ar,
+		accSvc:      accSvc,
+		retry:       retry,
+	}
+}
+
+func (s *StandingOrderService) Create(userID int, req *model.StandingOrderCreate) (*model.StandingOrder, error) {
+	acc, err := s.accountRepo.GetByID(req.FromAccountID)
+	if err != nil {
+		return nil, err
+	}
+	if acc.UserID != userID {
+		return nil, ErrAccessDenied
+	}
+	if _, err := s.accountRepo.GetByID(req.ToAccountID); err != nil {
+		return nil, err
+	}
+
+	today := truncateDay(time.Now())
+	start := truncateDay(req.StartDate)
+	if start.Before(today) {
+		return nil, ErrInvalidSchedule
+	}
+	if req.EndDate != nil && truncateDay(*req.EndDate).Before(start) {
+		return nil, ErrInvalidSchedule
+	}
+
+	order := &model.StandingOrder{
+		UserID:        userID,
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        req.Amount,
+		Frequency:     req.Frequency,
+		EndDate:       req.EndDate,
+		MaxExecutions: req.MaxExecutions,
+		Status:        model.StandingOrderActive,
+	}
+	if req.Frequency == model.FrequencyMonthly {
+		day := start.Day()
+		if req.DayOfMonth != nil {
+			day = *req.DayOfMonth
+		}
+		order.DayOfMonth = &day
+		first := monthlyDate(start.Year(), start.Month(), day, start.Location())
+		if first.Before(start) {
+			first = monthlyDate(start.Year(), start.Month()+1, day, start.Location())
+		}
+		start = first
+	}
+	order.ScheduledFor = start
+	order.NextRunAt = start
+
+	// шедулер платит без участия клиента, поэтому второй фактор
+	// подтверждается при создании поручения
+	if err := s.accSvc.AuthorizeTransfer(userID, req.Amount, req.OTP); err != nil {
+		return nil, err
+	}
+	if err := s.orderRepo.Create(order); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+func (s *StandingOrderService) List(userID int) ([]*model.StandingOrder, error) {
+	return s.orderRepo.ListByUser(userID)
+}
+
+func (s *StandingOrderService) Cancel(userID, orderID int) error {
+	order, err := s.getOwned(userID, orderID)
+	if err != nil {
+		return err
+	}
+	if order.Status != model.StandingOrderActive {
+		return ErrStandingOrderInactive
+	}
+	order.Status = model.StandingOrderCancelled
+	return s.orderRepo.Update(order)
+}
+
+func (s *StandingOrderService) ListExecutions(userID, orderID int) ([]*model.StandingOrderExecution, error) {
+	if _, err := s.getOwned(userID, orderID); err != nil {
+		return nil, err
+	}
+	return s.orderRepo.ListExecutions(orderID)
+}
+
+func (s *StandingOrderService) getOwned(userID, orderID int) (*model.StandingOrder, error) {
+	order, err := s.orderRepo.GetByID(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order.UserID != userID {
+		return nil, ErrStandingOrderNotYours
+	}
+	return order, nil
+}
+
+// ProcessDueOrders исполняет все поручения, срок которых наступил.
+// Ошибка по отдельному поручению не прерывает обработку остальных.
+func (s *StandingOrderService) ProcessDueOrders() error {
+	now := time.Now()
+	orders, err := s.orderRepo.ListDue(now)
+	if err != nil {
+		return err
+	}
+
+	for _, o := range orders {
+		if err := s.execute(o, now); err != nil {
+			log.Printf("Поручение #%d: ошибка сохранения результата: %v", o.ID, err)
+		}
+	}
+	return nil
+}
+
+func (s *StandingOrderService) execute(o *model.StandingOrder, now time.Time) error {
+	// поручение захватывается до перевода: другой экземпляр или
+	// наложившийся запуск шедулера его уже не выберет
+	if err := s.orderRepo.Claim(o, now.Add(standingOrderLease)); err != nil {
+		if errors.Is(err, repository.ErrStandingOrderClaimed) {
+			return nil
+		}
+		return err
+	}
+
+	o.Attempts++
+	scheduledFor := o.ScheduledFor
+	exec := &model.StandingOrderExecution{OrderID: o.ID, Attempt: o.Attempts, ScheduledFor: &scheduledFor}
+
+	// успешное исполнение записывается в транзакции перевода: ключ
+	// (поручение, плановая дата) не даст заплатить за одну дату дважды
+	_, err := s.accSvc.TransferAndRecord(o.UserID, o.FromAccountID, o.ToAccountID, o.Amount,
+		func(tx *sql.Tx, res *model.TransferResult) error {
+			success := *exec
+			success.Status = model.ExecutionSuccess
+			success.TransactionID = &res.Debit.ID
+			return s.orderRepo.CreateExecutionTx(tx, &success)
+		})
+	switch {
+	case err == nil, errors.Is(err, repository.ErrExecutionExists):
+		if err != nil {
+			log.Printf("Поручение #%d: платёж за %s уже проведён, переносим дату", o.ID, scheduledFor.Format("02.01.2006"))
+		}
+		o.ExecutionsCount++
+		s.advance(o)
+		return s.orderRepo.Update(o)
+	case retryable(err) && o.Attempts < s.retry.MaxAttempts:
+		exec.Status = model.ExecutionRetry
+		exec.Error = err.Error()
+		o.NextRunAt = now.Add(s.retry.Delay)
+	case retryable(err):
+		// попытки исчерпаны — пропускаем это исполнение и ждём следующего
+		exec.Status = model.ExecutionFailed
+		exec.Error = err.Error()
+		s.advance(o)
+	default:
+		// счёт удалён или больше не принадлежит пользователю — повторять бессмысленно
+		exec.Status = model.ExecutionFailed
+		exec.Error = err.Error()
+		o.Status = model.StandingOrderFailed
+	}
+
+	if err := s.orderRepo.CreateExecution(exec); err != nil {
+		return err
+	}
+	return s.orderRepo.Update(o)
+}
+
+// retryable сообщает, может ли повтор позже завершиться успешно.
+func retryable(err error) bool {
+	return errors.Is(err, ErrInsufficientFunds) || errors.Is(err, ErrLimitExceeded)
+}
+
+// advance переводит поручение на следующую плановую дату либо завершает его.
+func (s *StandingOrderService) advance(o *model.StandingOrder) {
+	o.Attempts = 0
+
+	var next time.Time
+	switch o.Frequency {
+	case model.FrequencyWeekly:
+		next = o.ScheduledFor.AddDate(0, 0, 7)
+	case model.FrequencyMonthly:
+		next = monthlyDate(o.ScheduledFor.Year(), o.ScheduledFor.Month()+1, *o.DayOfMonth, o.ScheduledFor.Location())
+	default:
+		o.Status = model.StandingOrderCompleted
+		return
+	}
+
+	if o.MaxExecutions != nil && o.ExecutionsCount >= *o.MaxExecutions {
+		o.Status = model.StandingOrderCompleted
+		return
+	}
+	if o.EndDate != nil && truncateDay(next).After(truncateDay(*o.EndDate)) {
+		o.Status = model.StandingOrderCompleted
+		return
+	}
+	o.ScheduledFor = next
+	o.NextRunAt = next
+}
+
+// monthlyDate возвращает day-е число месяца, а для коротких месяцев — последний день.
+func monthlyDate(year int, month time.Month, day int, loc *time.Location) time.Time {
+	last := time.Date(year, month+1, 0, 0, 0, 0, 0, loc).Day()
+	if day > last {
+		day = last
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"Bank/internal/model"
+	"Bank/internal/pan"
+	"Bank/internal/repository"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+)
+
+var (
+	ErrTokenScope    = errors.New("token is not valid for this scope")
+	ErrTokenInactive = errors.New("token is not active")
+)
+
+// TokenService — хранилище токенов карт. Внешние системы работают с токеном,
+// номер карты выдаётся по токену только внутренним системам банка и только
+// в пределах области токена. Токен действует, пока активна карта; при
+// перевыпуске карты токены переходят на новую карту.
+type TokenService struct {
+	tokenRepo repository.CardTokenRepository
+	cardRepo  repository.CardRepository
+	cardSvc   *CardService
+	auditSvc  *AuditService
+}
+
+func NewTokenService(
+	tr repository.CardTokenRepository,
+	cr repository.CardRepository,
+	cardSvc *CardService,
+	auditSvc *AuditService,
+) *TokenService {
+	return &TokenService{
+		tokenRepo: tr,
+		cardRepo:  cr,
+		cardSvc:   cardSvc,
+		auditSvc:  auditSvc,
+	}
+}
+
+// Issue выпускает токен для карты владельца.
+func (s *TokenService) Issue(userID, cardID int, req *model.TokenCreate) (*model.CardToken, error) {
+	c, err := s.cardSvc.ownedCard(userID, cardID)
+	if err != nil {
+		return nil, err
+	}
+	return s.issue(c, req)
+}
+
+// Tokenize выпускает токен по номеру карты; карта ищется по HMAC номера.
+func (s *TokenService) Tokenize(req *model.VaultTokenize) (*model.CardToken, error) {
+	c, err := s.cardSvc.CardByPAN(req.PAN)
+	if err != nil {
+		return nil, err
+	}
+	return s.issue(c, &req.TokenCreate)
+}
+
+// issue возвращает действующий токен карты для области и формата,
+// а если его нет — выпускает новый.
+func (s *TokenService) issue(c *model.Card, req *model.TokenCreate) (*model.CardToken, error) {
+	if c.Status != model.CardActive {
+		return nil, repository.ErrCardStatus
+	}
+	format := req.Format
+	if format == "" {
+		format = model.TokenFormatRandom
+	}
+
+	number := ""
+	if format == model.TokenFormatPAN {
+		var err error
+		if number, err = s.cardSvc.Number(c); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := 0; i < maxNumberAttempts; i++ {
+		t, err := s.tokenRepo.GetActive(c.ID, req.Scope, format)
+		if err == nil {
+			return t, nil
+		}
+		if !errors.Is(err, repository.ErrTokenNotFound) {
+			return nil, err
+		}
+
+		token, err := newToken(format, number)
+		if err != nil {
+			return nil, err
+		}
+		t = &model.CardToken{
+			CardID: c.ID,
+			Token:  token,
+			Format: format,
+			Scope:  req.Scope,
+		}
+		err = s.tokenRepo.Create(t)
+		if err == nil {
+			return t, nil
+		}
+		// совпал токен или параллельно выпущен токен той же области — пробуем ещё раз
+		if !errors.Is(err, repository.ErrTokenTaken) {
+			return nil, err
+		}
+	}
+	return nil, repository.ErrTokenTaken
+}
+
+// List возвращает токены карты владельца. Токены неактивной карты
+// показываются приостановленными.
+func (s *TokenService) List(userID, cardID int) ([]*model.CardToken, error) {
+	c, err := s.cardSvc.ownedCard(userID, cardID)
+	if err != nil {
+		return nil, err
+	}
+	list, err := s.tokenRepo.ListByCard(c.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range list {
+		if t.Status == model.TokenActive && c.Status != model.CardActive {
+			t.Status = model.TokenSuspended
+		}
+	}
+	return list, nil
+}
+
+// Delete отзывает токен карты владельца.
+func (s *TokenService) Delete(userID, cardID, tokenID int) error {
+	list, err := s.List(userID, cardID)
+	if err != nil {
+		return err
+	}
+	for _, t := range list {
+		if t.ID == tokenID {
+			return s.tokenRepo.Delete(t)
+		}
+	}
+	return repository.ErrTokenNotFound
+}
+
+// Detokenize выдаёт реквизиты карты по токену. Каждая выдача номера
+// записывается в журнал владельца карты.
+func (s *TokenService) Detokenize(req *model.VaultDetokenize, ip string) (*model.Detokenized, error) {
+	t, err := s.tokenRepo.GetByToken(req.Token)
+	if err != nil {
+		return nil, err
+	}
+	if t.Scope != req.Scope {
+		return nil, ErrTokenScope
+	}
+	c, err := s.cardRepo.GetByID(t.CardID)
+	if err != nil {
+		return nil, err
+	}
+	acc, err := s.cardSvc.Account(c)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := s.detokenize(t, c)
+	details := "scope " + t.Scope
+	if err != nil {
+		details += ": " + err.Error()
+	}
+	s.auditSvc.Record(acc.UserID, model.AuditDetokenize, c.ID, err == nil, ip, details)
+	return out, err
+}
+
+func (s *TokenService) detokenize(t *model.CardToken, c *model.Card) (*model.Detokenized, error) {
+	if t.Status != model.TokenActive || c.Status != model.CardActive {
+		return nil, ErrTokenInactive
+	}
+	number, err := s.cardSvc.Number(c)
+	if err != nil {
+		return nil, err
+	}
+	exp, err := s.cardSvc.Expiry(c)
+	if err != nil {
+		return nil, err
+	}
+	return &model.Detokenized{
+		CardID: c.ID,
+		PAN:    number,
+		Expiry: exp.Format(cardExpiryLayout),
+	}, nil
+}
+
+// newToken генерирует токен: формато-сохраняющий по номеру или случайный.
+func newToken(format, number string) (string, error) {
+	if format == model.TokenFormatPAN {
+		return pan.Token(number), nil
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "tok_" + hex.EncodeToString(b), nil
+}
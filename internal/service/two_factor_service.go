@@ -0,0 +1,259 @@
+package service
+
+import (
+	"Bank/internal/model"
+	"Bank/internal/repository"
+	"Bank/internal/totp"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrOTPRequired       = errors.New("one-time code required")
+	ErrInvalidOTP        = errors.New("invalid one-time code")
+	ErrTwoFactorRequired = errors.New("two-factor authentication must be enabled for this operation")
+)
+
+// recoveryCodeCount — сколько кодов восстановления выдаётся за раз.
+const recoveryCodeCount = 10
+
+// TwoFactorService — второй фактор TOTP (RFC 6238) и коды восстановления.
+// Секрет TOTP хранится зашифрованным ключом данных KeyManager. Политика
+// банка может требовать второй фактор для переводов крупнее порога.
+type TwoFactorService struct {
+	tfaRepo           repository.TwoFactorRepository
+	userRepo          repository.UserRepository
+	keys              *KeyManager
+	throttle          *LoginThrottleService
+	issuer            string
+	transferThreshold float64
+}
+
+func NewTwoFactorService(
+	tr repository.TwoFactorRepository,
+	ur repository.UserRepository,
+	keys *KeyManager,
+	throttle *LoginThrottleService,
+	issuer string,
+	transferThreshold float64,
+) *TwoFactorService {
+	return &TwoFactorService{
+		tfaRepo:           tr,
+		userRepo:          ur,
+		keys:              keys,
+		throttle:          throttle,
+		issuer:            issuer,
+		transferThreshold: transferThreshold,
+	}
+}
+
+// Setup создаёт новый секрет. Второй фактор включается только после
+// подтверждения первым кодом (Confirm), до этого setup можно повторять.
+func (s *TwoFactorService) Setup(userID int) (*model.TOTPSetup, error) {
+	u, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := totp.NewSecret()
+	if err != nil {
+		return nil, err
+	}
+	keyID, err := s.keys.ActiveKeyID()
+	if err != nil {
+		return nil, err
+	}
+	enc, err := s.keys.Encrypt(keyID, []byte(secret))
+	if err != nil {
+		return nil, err
+	}
+	t := &model.UserTOTP{UserID: userID, SecretEncrypted: enc, KeyID: keyID}
+	if err := s.tfaRepo.SaveTOTP(t); err != nil {
+		return nil, err
+	}
+	return &model.TOTPSetup{
+		Secret: secret,
+		URI:    totp.URI(s.issuer, u.Email, secret),
+	}, nil
+}
+
+// Confirm включает второй фактор по первому коду и выдаёт коды восстановления.
+func (s *TwoFactorService) Confirm(userID int, code string) (*model.RecoveryCodes, error) {
+	t, err := s.tfaRepo.GetTOTP(userID)
+	if err != nil {
+		return nil, err
+	}
+	if t.Enabled {
+		return nil, repository.ErrTOTPEnabled
+	}
+	counter, err := s.validate(t, code)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.tfaRepo.Enable(userID, counter); err != nil {
+		return nil, err
+	}
+	return s.issueRecoveryCodes(userID)
+}
+
+// Disable отключает второй фактор и удаляет коды восстановления.
+func (s *TwoFactorService) Disable(userID int) error {
+	if _, err := s.tfaRepo.GetTOTP(userID); err != nil {
+		return err
+	}
+	return s.tfaRepo.DeleteTOTP(userID)
+}
+
+// RegenerateRecoveryCodes заменяет все коды восстановления новыми.
+func (s *TwoFactorService) RegenerateRecoveryCodes(userID int) (*model.RecoveryCodes, error) {
+	enabled, err := s.Enabled(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return nil, repository.ErrTOTPNotFound
+	}
+	return s.issueRecoveryCodes(userID)
+}
+
+func (s *TwoFactorService) Status(userID int) (*model.TwoFactorStatus, error) {
+	t, err := s.tfaRepo.GetTOTP(userID)
+	if errors.Is(err, repository.ErrTOTPNotFound) || err == nil && !t.Enabled {
+		return &model.TwoFactorStatus{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	left, err := s.tfaRepo.CountRecoveryCodes(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &model.TwoFactorStatus{Enabled: true, EnabledAt: t.EnabledAt, RecoveryCodesLeft: left}, nil
+}
+
+func (s *TwoFactorService) Enabled(userID int) (bool, error) {
+	t, err := s.tfaRepo.GetTOTP(userID)
+	if errors.Is(err, repository.ErrTOTPNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return t.Enabled, nil
+}
+
+// Check проверяет второй фактор, если он включён у пользователя; otp — код
+// из приложения или код восстановления. Без включённого фактора проходит всегда.
+func (s *TwoFactorService) Check(userID int, otp string) error {
+	t, err := s.tfaRepo.GetTOTP(userID)
+	if errors.Is(err, repository.ErrTOTPNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !t.Enabled {
+		return nil
+	}
+	return s.verify(t, otp)
+}
+
+// RequireForAmount применяет политику банка к переводу: выше порога
+// второй фактор обязателен и должен быть подтверждён кодом.
+func (s *TwoFactorService) RequireForAmount(userID int, amount float64, otp string) error {
+	if s.transferThreshold <= 0 || amount <= s.transferThreshold {
+		return nil
+	}
+	t, err := s.tfaRepo.GetTOTP(userID)
+	if errors.Is(err, repository.ErrTOTPNotFound) || err == nil && !t.Enabled {
+		return ErrTwoFactorRequired
+	}
+	if err != nil {
+		return err
+	}
+	return s.verify(t, otp)
+}
+
+// verify проверяет код с учётом счётчика неудачных проверок пользователя:
+// после нескольких неверных кодов подряд попытки задерживаются и блокируются.
+func (s *TwoFactorService) verify(t *model.UserTOTP, otp string) error {
+	otp = strings.TrimSpace(otp)
+	if otp == "" {
+		return ErrOTPRequired
+	}
+	if err := s.throttle.CheckVerification(t.UserID); err != nil {
+		return err
+	}
+	err := s.verifyCode(t, otp)
+	switch {
+	case errors.Is(err, ErrInvalidOTP):
+		if ferr := s.throttle.VerificationFailed(t.UserID); ferr != nil {
+			return ferr
+		}
+	case err == nil:
+		return s.throttle.VerificationSucceeded(t.UserID)
+	}
+	return err
+}
+
+func (s *TwoFactorService) verifyCode(t *model.UserTOTP, otp string) error {
+	if len(otp) == totp.Digits {
+		counter, err := s.validate(t, otp)
+		if err != nil {
+			return err
+		}
+		err = s.tfaRepo.UseCounter(t.UserID, counter)
+		if errors.Is(err, repository.ErrOTPReplayed) {
+			return ErrInvalidOTP
+		}
+		return err
+	}
+
+	ok, err := s.tfaRepo.UseRecoveryCode(t.UserID, hashSecret(normalizeRecoveryCode(otp)))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidOTP
+	}
+	return nil
+}
+
+// validate сверяет код с секретом и возвращает его временной шаг.
+func (s *TwoFactorService) validate(t *model.UserTOTP, code string) (int64, error) {
+	secret, err := s.keys.Decrypt(&t.KeyID, t.SecretEncrypted)
+	if err != nil {
+		return 0, err
+	}
+	counter, ok := totp.Validate(string(secret), code, time.Now())
+	if !ok || counter <= t.LastCounter {
+		return 0, ErrInvalidOTP
+	}
+	return counter, nil
+}
+
+func (s *TwoFactorService) issueRecoveryCodes(userID int) (*model.RecoveryCodes, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		code := base32.StdEncoding.EncodeToString(b)
+		codes[i] = code[:4] + "-" + code[4:]
+		hashes[i] = hashSecret(code)
+	}
+	if err := s.tfaRepo.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+	return &model.RecoveryCodes{Codes: codes}, nil
+}
+
+// normalizeRecoveryCode убирает разделители и приводит код к верхнему регистру.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToUpper(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
@@ -0,0 +1,4 @@
+-- migrations/0005_standing_orders.down.sql
+
+DROP TABLE IF EXISTS standing_order_executions;
+DROP TABLE IF EXISTS standing_orders;
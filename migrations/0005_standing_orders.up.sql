@@ -0,0 +1,34 @@
+-- migrations/0005_standing_orders.up.sql
+
+-- Регулярные и отложенные переводы
+CREATE TABLE standing_orders (
+                                 id               SERIAL PRIMARY KEY,
+                                 user_id          INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                 from_account_id  INTEGER NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+                                 to_account_id    INTEGER NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+                                 amount           NUMERIC(18,2) NOT NULL,
+                                 frequency        VARCHAR(10) NOT NULL,             -- 'once','weekly','monthly'
+                                 day_of_month     INTEGER,                          -- для monthly
+                                 end_date         DATE,
+                                 max_executions   INTEGER,
+                                 executions_count INTEGER NOT NULL DEFAULT 0,
+                                 scheduled_for    TIMESTAMP WITH TIME ZONE NOT NULL, -- плановая дата текущего исполнения
+                                 next_run_at      TIMESTAMP WITH TIME ZONE NOT NULL, -- с учётом повторов
+                                 attempts         INTEGER NOT NULL DEFAULT 0,
+                                 status           VARCHAR(20) NOT NULL DEFAULT 'active', -- 'active','completed','cancelled','failed'
+                                 created_at       TIMESTAMP WITH TIME ZONE DEFAULT now()
+);
+
+-- Журнал исполнений
+CREATE TABLE standing_order_executions (
+                                           id              SERIAL PRIMARY KEY,
+                                           order_id        INTEGER NOT NULL REFERENCES standing_orders(id) ON DELETE CASCADE,
+                                           attempt         INTEGER NOT NULL,
+                                           status          VARCHAR(20) NOT NULL, -- 'success','retry','failed'
+                                           transaction_id  INTEGER REFERENCES transactions(id) ON DELETE SET NULL,
+                                           error           TEXT,
+                                           executed_at     TIMESTAMP WITH TIME ZONE DEFAULT now()
+);
+
+CREATE INDEX ON standing_orders(status, next_run_at);
+CREATE INDEX ON standing_order_executions(order_id);
@@ -0,0 +1,4 @@
+-- migrations/0006_recipient_aliases.down.sql
+
+DROP TABLE IF EXISTS transfer_confirmations;
+DROP TABLE IF EXISTS phone_aliases;
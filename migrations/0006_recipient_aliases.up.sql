@@ -0,0 +1,24 @@
+-- migrations/0006_recipient_aliases.up.sql
+
+-- Телефонные псевдонимы для входящих переводов
+CREATE TABLE phone_aliases (
+                               phone       VARCHAR(20) PRIMARY KEY,                 -- E.164, например +79991234567
+                               user_id     INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                               account_id  INTEGER NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+                               created_at  TIMESTAMP WITH TIME ZONE DEFAULT now()
+);
+
+-- Ожидающие подтверждения переводы по псевдониму получателя
+CREATE TABLE transfer_confirmations (
+                                        token            VARCHAR(64) PRIMARY KEY,
+                                        user_id          INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                        from_account_id  INTEGER NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+                                        to_account_id    INTEGER NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+                                        amount           NUMERIC(18,2) NOT NULL,
+                                        recipient_name   TEXT NOT NULL,               -- маскированное имя
+                                        expires_at       TIMESTAMP WITH TIME ZONE NOT NULL,
+                                        confirmed_at     TIMESTAMP WITH TIME ZONE,
+                                        created_at       TIMESTAMP WITH TIME ZONE DEFAULT now()
+);
+
+CREATE INDEX ON phone_aliases(user_id);
@@ -0,0 +1,3 @@
+-- migrations/0007_payees.down.sql
+
+DROP TABLE IF EXISTS payees;
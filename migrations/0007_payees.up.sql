@@ -0,0 +1,16 @@
+-- migrations/0007_payees.up.sql
+
+-- Сохранённые получатели (адресная книга)
+CREATE TABLE payees (
+                        id              SERIAL PRIMARY KEY,
+                        user_id         INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                        nickname        VARCHAR(50) NOT NULL,
+                        to_account_id   INTEGER REFERENCES accounts(id) ON DELETE CASCADE,
+                        recipient_type  VARCHAR(10),                 -- 'username','email','phone'
+                        recipient       VARCHAR(100),
+                        default_amount  NUMERIC(18,2),
+                        description     TEXT NOT NULL DEFAULT '',
+                        created_at      TIMESTAMP WITH TIME ZONE DEFAULT now(),
+                        UNIQUE (user_id, nickname),
+                        CHECK ((to_account_id IS NULL) <> (recipient IS NULL))
+);
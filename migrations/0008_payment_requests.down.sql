@@ -0,0 +1,3 @@
+-- migrations/0008_payment_requests.down.sql
+
+DROP TABLE IF EXISTS payment_requests;
@@ -0,0 +1,19 @@
+-- migrations/0008_payment_requests.up.sql
+
+-- Запросы денег и платёжные ссылки
+CREATE TABLE payment_requests (
+                                  id                     SERIAL PRIMARY KEY,
+                                  user_id                INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                  to_account_id          INTEGER NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+                                  amount                 NUMERIC(18,2) NOT NULL,
+                                  description            TEXT NOT NULL DEFAULT '',
+                                  status                 VARCHAR(20) NOT NULL DEFAULT 'pending', -- 'pending','processing','paid','cancelled'
+                                  expires_at             TIMESTAMP WITH TIME ZONE NOT NULL,
+                                  payer_user_id          INTEGER REFERENCES users(id) ON DELETE SET NULL,
+                                  debit_transaction_id   INTEGER REFERENCES transactions(id) ON DELETE SET NULL,
+                                  credit_transaction_id  INTEGER REFERENCES transactions(id) ON DELETE SET NULL,
+                                  paid_at                TIMESTAMP WITH TIME ZONE,
+                                  created_at             TIMESTAMP WITH TIME ZONE DEFAULT now()
+);
+
+CREATE INDEX ON payment_requests(user_id);
@@ -0,0 +1,4 @@
+-- migrations/0009_fees.down.sql
+
+DROP TABLE IF EXISTS fee_usage;
+DROP TABLE IF EXISTS fee_schedules;
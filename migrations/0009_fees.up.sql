@@ -0,0 +1,32 @@
+-- migrations/0009_fees.up.sql
+
+-- Тарифы комиссий по типам операций
+CREATE TABLE fee_schedules (
+                               id              SERIAL PRIMARY KEY,
+                               operation       VARCHAR(30) NOT NULL UNIQUE,      -- 'withdraw','transfer_p2p','fx','card_issue'
+                               rule_type       VARCHAR(10) NOT NULL,             -- 'flat','percent','tiered'
+                               flat_amount     NUMERIC(18,2) NOT NULL DEFAULT 0,
+                               percent         NUMERIC(6,3)  NOT NULL DEFAULT 0, -- 1.5 = 1.5%
+                               min_fee         NUMERIC(18,2) NOT NULL DEFAULT 0,
+                               max_fee         NUMERIC(18,2) NOT NULL DEFAULT 0, -- 0 = без ограничения
+                               tiers           JSONB,                            -- [{"up_to":100000,"flat":0,"percent":0}, ...]
+                               free_per_month  INTEGER NOT NULL DEFAULT 0,
+                               active          BOOLEAN NOT NULL DEFAULT TRUE,
+                               created_at      TIMESTAMP WITH TIME ZONE DEFAULT now()
+);
+
+-- Счётчик операций пользователя за месяц для бесплатных лимитов
+CREATE TABLE fee_usage (
+                           user_id    INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                           operation  VARCHAR(30) NOT NULL,
+                           period     DATE NOT NULL,                               -- первое число месяца
+                           count      INTEGER NOT NULL DEFAULT 0,
+                           PRIMARY KEY (user_id, operation, period)
+);
+
+INSERT INTO fee_schedules(operation, rule_type, flat_amount, percent, min_fee, max_fee, tiers, free_per_month) VALUES
+    ('withdraw',     'percent', 0,   1.0, 50, 3000, NULL, 3),
+    ('transfer_p2p', 'tiered',  0,   0,   0,  1500,
+        '[{"up_to":100000,"flat":0,"percent":0},{"up_to":null,"flat":0,"percent":0.5}]', 0),
+    ('fx',           'percent', 0,   1.5, 0,  0,    NULL, 0),
+    ('card_issue',   'flat',    300, 0,   0,  0,    NULL, 1);
@@ -0,0 +1,4 @@
+-- migrations/0010_transaction_limits.down.sql
+
+DROP INDEX IF EXISTS transactions_account_created_idx;
+DROP TABLE IF EXISTS transaction_limits;
@@ -0,0 +1,18 @@
+-- migrations/0010_transaction_limits.up.sql
+
+-- Лимиты на снятие и исходящие переводы.
+-- account_id IS NULL — лимит пользователя по всем счетам, иначе — для конкретного счёта.
+CREATE TABLE transaction_limits (
+                                    id                 SERIAL PRIMARY KEY,
+                                    user_id            INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                    account_id         INTEGER REFERENCES accounts(id) ON DELETE CASCADE,
+                                    operation          VARCHAR(20) NOT NULL,   -- 'withdraw','transfer'
+                                    daily_limit        NUMERIC(18,2),
+                                    monthly_limit      NUMERIC(18,2),
+                                    per_operation_max  NUMERIC(18,2),
+                                    updated_at         TIMESTAMP WITH TIME ZONE DEFAULT now()
+);
+
+CREATE UNIQUE INDEX transaction_limits_scope_idx
+    ON transaction_limits(user_id, operation, COALESCE(account_id, 0));
+CREATE INDEX transactions_account_created_idx ON transactions(account_id, created_at);
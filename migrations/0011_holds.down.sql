@@ -0,0 +1,3 @@
+-- migrations/0011_holds.down.sql
+
+DROP TABLE IF EXISTS holds;
@@ -0,0 +1,18 @@
+-- migrations/0011_holds.up.sql
+
+-- Блокировки средств (холды): уменьшают доступный, но не учётный баланс
+CREATE TABLE holds (
+                       id               SERIAL PRIMARY KEY,
+                       account_id       INTEGER NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+                       amount           NUMERIC(18,2) NOT NULL,
+                       captured_amount  NUMERIC(18,2) NOT NULL DEFAULT 0,
+                       status           VARCHAR(20) NOT NULL DEFAULT 'active', -- 'active','captured','released','expired'
+                       description      TEXT NOT NULL DEFAULT '',
+                       transaction_id   INTEGER REFERENCES transactions(id) ON DELETE SET NULL,
+                       expires_at       TIMESTAMP WITH TIME ZONE NOT NULL,
+                       created_at       TIMESTAMP WITH TIME ZONE DEFAULT now(),
+                       updated_at       TIMESTAMP WITH TIME ZONE DEFAULT now()
+);
+
+CREATE INDEX ON holds(account_id, status);
+CREATE INDEX ON holds(status, expires_at);
@@ -0,0 +1,7 @@
+-- migrations/0012_reversals_disputes.down.sql
+
+DROP TABLE IF EXISTS disputes;
+DROP INDEX IF EXISTS transactions_reversal_of_idx;
+ALTER TABLE transactions
+    DROP COLUMN IF EXISTS reversal_of,
+    DROP COLUMN IF EXISTS counterpart_id;
@@ -0,0 +1,27 @@
+-- migrations/0012_reversals_disputes.up.sql
+
+-- Связи проводок: вторая нога перевода и отменяемая операция.
+-- Уникальность reversal_of не даёт отменить операцию дважды.
+ALTER TABLE transactions
+    ADD COLUMN counterpart_id INTEGER REFERENCES transactions(id) ON DELETE SET NULL,
+    ADD COLUMN reversal_of    INTEGER REFERENCES transactions(id) ON DELETE SET NULL;
+
+CREATE UNIQUE INDEX transactions_reversal_of_idx ON transactions(reversal_of) WHERE reversal_of IS NOT NULL;
+
+-- Споры клиентов по операциям
+CREATE TABLE disputes (
+                          id              SERIAL PRIMARY KEY,
+                          transaction_id  INTEGER NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+                          user_id         INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                          reason          TEXT NOT NULL,
+                          status          VARCHAR(20) NOT NULL DEFAULT 'open', -- 'open','under_review','resolved_favor','resolved_against'
+                          resolution      TEXT NOT NULL DEFAULT '',
+                          created_at      TIMESTAMP WITH TIME ZONE DEFAULT now(),
+                          updated_at      TIMESTAMP WITH TIME ZONE DEFAULT now()
+);
+
+-- По операции может быть только один незакрытый спор
+CREATE UNIQUE INDEX disputes_open_transaction_idx
+    ON disputes(transaction_id) WHERE status IN ('open', 'under_review');
+CREATE INDEX ON disputes(user_id);
+CREATE INDEX ON disputes(status);
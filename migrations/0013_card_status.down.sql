@@ -0,0 +1,7 @@
+-- migrations/0013_card_status.down.sql
+
+DROP INDEX IF EXISTS cards_status_idx;
+ALTER TABLE cards
+    DROP COLUMN IF EXISTS status_changed_at,
+    DROP COLUMN IF EXISTS replaced_by,
+    DROP COLUMN IF EXISTS status;
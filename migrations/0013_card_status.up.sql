@@ -0,0 +1,9 @@
+-- migrations/0013_card_status.up.sql
+
+-- Жизненный цикл карты. replaced_by — карта, выпущенная взамен перевыпущенной.
+ALTER TABLE cards
+    ADD COLUMN status            VARCHAR(20) NOT NULL DEFAULT 'active', -- 'active','blocked_user','blocked_bank','expired','reissued'
+    ADD COLUMN replaced_by       INTEGER REFERENCES cards(id) ON DELETE SET NULL,
+    ADD COLUMN status_changed_at TIMESTAMP WITH TIME ZONE DEFAULT now();
+
+CREATE INDEX cards_status_idx ON cards(status);
@@ -0,0 +1,4 @@
+-- migrations/0014_card_authorizations.down.sql
+
+DROP INDEX IF EXISTS cards_hmac_idx;
+DROP TABLE IF EXISTS card_authorizations;
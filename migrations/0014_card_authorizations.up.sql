@@ -0,0 +1,24 @@
+-- migrations/0014_card_authorizations.up.sql
+
+-- Журнал авторизаций по картам от эквайера: запрос 0100 ставит холд,
+-- клиринг 0220 списывает его, реверсал 0400 освобождает.
+CREATE TABLE card_authorizations (
+                                     id               SERIAL PRIMARY KEY,
+                                     card_id          INTEGER REFERENCES cards(id) ON DELETE SET NULL,
+                                     hold_id          INTEGER REFERENCES holds(id) ON DELETE SET NULL,
+                                     stan             VARCHAR(6)  NOT NULL,
+                                     rrn              VARCHAR(12) NOT NULL,
+                                     approval_code    VARCHAR(6),
+                                     response_code    VARCHAR(2)  NOT NULL,
+                                     amount           NUMERIC(18,2) NOT NULL,
+                                     captured_amount  NUMERIC(18,2) NOT NULL DEFAULT 0,
+                                     merchant_id      VARCHAR(15) NOT NULL DEFAULT '',
+                                     merchant_name    VARCHAR(40) NOT NULL DEFAULT '',
+                                     mcc              VARCHAR(4)  NOT NULL DEFAULT '',
+                                     status           VARCHAR(20) NOT NULL,  -- 'approved','declined','cleared','reversed'
+                                     created_at       TIMESTAMP WITH TIME ZONE DEFAULT now(),
+                                     updated_at       TIMESTAMP WITH TIME ZONE DEFAULT now()
+);
+
+CREATE INDEX ON card_authorizations(card_id, approval_code);
+CREATE INDEX cards_hmac_idx ON cards(hmac);
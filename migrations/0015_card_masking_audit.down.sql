@@ -0,0 +1,6 @@
+-- migrations/0015_card_masking_audit.down.sql
+
+DROP TABLE IF EXISTS audit_log;
+ALTER TABLE cards
+    DROP COLUMN IF EXISTS payment_system,
+    DROP COLUMN IF EXISTS masked_number;
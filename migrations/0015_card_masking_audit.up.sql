@@ -0,0 +1,21 @@
+-- migrations/0015_card_masking_audit.up.sql
+
+-- Маскированный номер и платёжная система хранятся открыто, чтобы
+-- список карт не требовал расшифровки PAN. Старые карты заполняются при первом чтении.
+ALTER TABLE cards
+    ADD COLUMN masked_number  VARCHAR(19) NOT NULL DEFAULT '',
+    ADD COLUMN payment_system VARCHAR(20) NOT NULL DEFAULT '';
+
+-- Журнал чувствительных действий пользователей
+CREATE TABLE audit_log (
+                           id           SERIAL PRIMARY KEY,
+                           user_id      INTEGER REFERENCES users(id) ON DELETE SET NULL,
+                           action       VARCHAR(50) NOT NULL,   -- например 'card_reveal'
+                           target_id    INTEGER,
+                           success      BOOLEAN NOT NULL,
+                           ip           VARCHAR(45) NOT NULL DEFAULT '',
+                           details      TEXT NOT NULL DEFAULT '',
+                           created_at   TIMESTAMP WITH TIME ZONE DEFAULT now()
+);
+
+CREATE INDEX ON audit_log(user_id, action, created_at);
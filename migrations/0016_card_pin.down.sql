@@ -0,0 +1,6 @@
+-- migrations/0016_card_pin.down.sql
+
+UPDATE cards SET status = 'active' WHERE status = 'blocked_pin';
+ALTER TABLE cards
+    DROP COLUMN IF EXISTS pin_attempts,
+    DROP COLUMN IF EXISTS pin_hash;
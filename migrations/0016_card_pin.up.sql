@@ -0,0 +1,7 @@
+-- migrations/0016_card_pin.up.sql
+
+-- PIN карты: argon2id-хеш в формате PHC и счётчик неверных попыток.
+-- После исчерпания попыток карта получает статус 'blocked_pin'.
+ALTER TABLE cards
+    ADD COLUMN pin_hash     TEXT    NOT NULL DEFAULT '',
+    ADD COLUMN pin_attempts INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,11 @@
+-- migrations/0017_card_controls.down.sql
+
+DROP INDEX IF EXISTS card_authorizations_card_created_idx;
+ALTER TABLE cards
+    DROP COLUMN IF EXISTS offline_enabled,
+    DROP COLUMN IF EXISTS online_enabled,
+    DROP COLUMN IF EXISTS allowed_countries,
+    DROP COLUMN IF EXISTS allowed_mcc,
+    DROP COLUMN IF EXISTS per_transaction_max,
+    DROP COLUMN IF EXISTS monthly_limit,
+    DROP COLUMN IF EXISTS daily_limit;
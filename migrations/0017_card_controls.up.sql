@@ -0,0 +1,14 @@
+-- migrations/0017_card_controls.up.sql
+
+-- Ограничения по карте, которые задаёт владелец. NULL в лимитах и пустые
+-- списки означают отсутствие ограничения.
+ALTER TABLE cards
+    ADD COLUMN daily_limit         NUMERIC(18,2),
+    ADD COLUMN monthly_limit       NUMERIC(18,2),
+    ADD COLUMN per_transaction_max NUMERIC(18,2),
+    ADD COLUMN allowed_mcc         TEXT[]  NOT NULL DEFAULT '{}',
+    ADD COLUMN allowed_countries   TEXT[]  NOT NULL DEFAULT '{}',
+    ADD COLUMN online_enabled      BOOLEAN NOT NULL DEFAULT TRUE,
+    ADD COLUMN offline_enabled     BOOLEAN NOT NULL DEFAULT TRUE;
+
+CREATE INDEX card_authorizations_card_created_idx ON card_authorizations(card_id, created_at);
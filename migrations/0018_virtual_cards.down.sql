@@ -0,0 +1,7 @@
+-- migrations/0018_virtual_cards.down.sql
+
+UPDATE cards SET status = 'blocked_bank' WHERE status = 'used';
+ALTER TABLE cards
+    DROP COLUMN IF EXISTS valid_until,
+    DROP COLUMN IF EXISTS spend_cap,
+    DROP COLUMN IF EXISTS kind;
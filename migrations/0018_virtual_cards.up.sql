@@ -0,0 +1,8 @@
+-- migrations/0018_virtual_cards.up.sql
+
+-- Виртуальные и одноразовые карты: лимит расходов на всё время жизни
+-- карты и точный момент окончания действия.
+ALTER TABLE cards
+    ADD COLUMN kind        VARCHAR(20) NOT NULL DEFAULT 'physical', -- 'physical','virtual','single_use'
+    ADD COLUMN spend_cap   NUMERIC(18,2),
+    ADD COLUMN valid_until TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,6 @@
+-- migrations/0019_card_products.down.sql
+
+DROP INDEX IF EXISTS cards_hmac_key;
+CREATE INDEX cards_hmac_idx ON cards(hmac);
+
+ALTER TABLE cards DROP COLUMN IF EXISTS product;
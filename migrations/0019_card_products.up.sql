@@ -0,0 +1,8 @@
+-- migrations/0019_card_products.up.sql
+
+-- Карточный продукт (диапазон BIN), из которого выпущен номер.
+-- Уникальный HMAC гарантирует, что номер не выдан повторно.
+ALTER TABLE cards ADD COLUMN product VARCHAR(30) NOT NULL DEFAULT '';
+
+DROP INDEX IF EXISTS cards_hmac_idx;
+CREATE UNIQUE INDEX cards_hmac_key ON cards(hmac);
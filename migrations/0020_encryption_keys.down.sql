@@ -0,0 +1,4 @@
+-- migrations/0020_encryption_keys.down.sql
+
+ALTER TABLE cards DROP COLUMN IF EXISTS key_id;
+DROP TABLE IF EXISTS encryption_keys;
@@ -0,0 +1,19 @@
+-- migrations/0020_encryption_keys.up.sql
+
+-- Ключи шифрования данных (DEK). Каждый ключ хранится зашифрованным
+-- мастер-ключом банка (PGP); активным может быть только один.
+CREATE TABLE encryption_keys (
+                                 id           SERIAL PRIMARY KEY,
+                                 wrapped_key  BYTEA NOT NULL,
+                                 status       VARCHAR(10) NOT NULL DEFAULT 'active', -- active | retired
+                                 created_at   TIMESTAMP WITH TIME ZONE DEFAULT now(),
+                                 retired_at   TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX encryption_keys_active_key ON encryption_keys(status) WHERE status = 'active';
+
+-- Ключ, которым зашифрованы реквизиты карты. NULL — карта зашифрована
+-- напрямую мастер-ключом (до появления DEK) и ждёт перешифрования.
+ALTER TABLE cards ADD COLUMN key_id INTEGER REFERENCES encryption_keys(id);
+
+CREATE INDEX ON cards(key_id);
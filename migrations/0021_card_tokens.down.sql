@@ -0,0 +1,3 @@
+-- migrations/0021_card_tokens.down.sql
+
+DROP TABLE IF EXISTS card_tokens;
@@ -0,0 +1,17 @@
+-- migrations/0021_card_tokens.up.sql
+
+-- Хранилище токенов карт: внешние системы получают токен вместо PAN.
+-- Сам токен не содержит данных карты, номер берётся из cards при детокенизации.
+CREATE TABLE card_tokens (
+                             id          SERIAL PRIMARY KEY,
+                             card_id     INTEGER NOT NULL REFERENCES cards(id) ON DELETE CASCADE,
+                             token       VARCHAR(64) NOT NULL UNIQUE,
+                             format      VARCHAR(10) NOT NULL,                   -- pan | random
+                             scope       VARCHAR(100) NOT NULL,                  -- мерчант или назначение токена
+                             status      VARCHAR(10) NOT NULL DEFAULT 'active',  -- active | deleted
+                             created_at  TIMESTAMP WITH TIME ZONE DEFAULT now(),
+                             updated_at  TIMESTAMP WITH TIME ZONE DEFAULT now()
+);
+
+-- Один действующий токен на карту, область и формат
+CREATE UNIQUE INDEX card_tokens_scope_key ON card_tokens(card_id, scope, format) WHERE status = 'active';
@@ -0,0 +1,12 @@
+-- migrations/0022_card_transactions.down.sql
+
+UPDATE card_transactions SET status = 'approved' WHERE status = 'authorized';
+UPDATE card_transactions SET status = 'cleared' WHERE status = 'settled';
+
+ALTER TABLE card_transactions
+    DROP COLUMN IF EXISTS transaction_id,
+    DROP COLUMN IF EXISTS merchant_country,
+    DROP COLUMN IF EXISTS merchant_city;
+
+ALTER INDEX card_transactions_card_created_idx RENAME TO card_authorizations_card_created_idx;
+ALTER TABLE card_transactions RENAME TO card_authorizations;
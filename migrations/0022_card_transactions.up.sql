@@ -0,0 +1,17 @@
+-- migrations/0022_card_transactions.up.sql
+
+-- Журнал авторизаций становится историей карточных операций: город и страна
+-- мерчанта, ссылка на проводку по счёту после расчёта. Статусы приводятся
+-- к жизненному циклу операции: authorized → settled или reversed.
+ALTER TABLE card_authorizations RENAME TO card_transactions;
+ALTER INDEX card_authorizations_card_created_idx RENAME TO card_transactions_card_created_idx;
+
+ALTER TABLE card_transactions
+    ADD COLUMN merchant_city    VARCHAR(13) NOT NULL DEFAULT '',
+    ADD COLUMN merchant_country VARCHAR(2)  NOT NULL DEFAULT '',
+    ADD COLUMN transaction_id   INTEGER REFERENCES transactions(id) ON DELETE SET NULL;
+
+UPDATE card_transactions SET status = 'authorized' WHERE status = 'approved';
+UPDATE card_transactions SET status = 'settled' WHERE status = 'cleared';
+UPDATE card_transactions ct SET transaction_id = h.transaction_id
+FROM holds h WHERE h.id = ct.hold_id AND ct.status = 'settled';
@@ -0,0 +1,3 @@
+-- migrations/0023_sessions.down.sql
+
+DROP TABLE IF EXISTS sessions;
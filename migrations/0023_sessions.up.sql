@@ -0,0 +1,19 @@
+-- migrations/0023_sessions.up.sql
+
+-- Сессии входа. Access-токен ссылается на сессию (jti), refresh-токен
+-- хранится только хешем и меняется при каждом обновлении; previous_hash
+-- нужен, чтобы распознать повторное использование украденного токена.
+CREATE TABLE sessions (
+                          id             VARCHAR(32) PRIMARY KEY,
+                          user_id        INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                          refresh_hash   VARCHAR(64) NOT NULL,
+                          previous_hash  VARCHAR(64) NOT NULL DEFAULT '',
+                          ip             VARCHAR(45) NOT NULL DEFAULT '',
+                          user_agent     VARCHAR(255) NOT NULL DEFAULT '',
+                          expires_at     TIMESTAMP WITH TIME ZONE NOT NULL,
+                          revoked_at     TIMESTAMP WITH TIME ZONE,
+                          created_at     TIMESTAMP WITH TIME ZONE DEFAULT now(),
+                          last_used_at   TIMESTAMP WITH TIME ZONE DEFAULT now()
+);
+
+CREATE INDEX ON sessions(user_id);
@@ -0,0 +1,4 @@
+-- migrations/0024_two_factor.down.sql
+
+DROP TABLE IF EXISTS recovery_codes;
+DROP TABLE IF EXISTS user_totp;
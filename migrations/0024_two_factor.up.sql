@@ -0,0 +1,24 @@
+-- migrations/0024_two_factor.up.sql
+
+-- Второй фактор TOTP. Секрет шифруется ключом данных (encryption_keys);
+-- last_counter — последний принятый шаг, чтобы код нельзя было использовать дважды.
+CREATE TABLE user_totp (
+                           user_id           INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+                           secret_encrypted  BYTEA NOT NULL,
+                           key_id            INTEGER NOT NULL REFERENCES encryption_keys(id),
+                           enabled           BOOLEAN NOT NULL DEFAULT FALSE,
+                           last_counter      BIGINT NOT NULL DEFAULT 0,
+                           created_at        TIMESTAMP WITH TIME ZONE DEFAULT now(),
+                           enabled_at        TIMESTAMP WITH TIME ZONE
+);
+
+-- Одноразовые коды восстановления; хранится только SHA-256 кода.
+CREATE TABLE recovery_codes (
+                                id          SERIAL PRIMARY KEY,
+                                user_id     INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                code_hash   VARCHAR(64) NOT NULL,
+                                used_at     TIMESTAMP WITH TIME ZONE,
+                                created_at  TIMESTAMP WITH TIME ZONE DEFAULT now()
+);
+
+CREATE INDEX ON recovery_codes(user_id);
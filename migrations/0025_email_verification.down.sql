@@ -0,0 +1,4 @@
+-- migrations/0025_email_verification.down.sql
+
+DROP TABLE IF EXISTS password_resets;
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified_at;
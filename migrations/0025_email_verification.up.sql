@@ -0,0 +1,17 @@
+-- migrations/0025_email_verification.up.sql
+
+-- Подтверждение email. Уже зарегистрированные пользователи считаются подтверждёнными.
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMP WITH TIME ZONE;
+UPDATE users SET email_verified_at = created_at;
+
+-- Одноразовые токены сброса пароля; хранится только SHA-256 токена.
+CREATE TABLE password_resets (
+                                 id          SERIAL PRIMARY KEY,
+                                 user_id     INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                 token_hash  VARCHAR(64) NOT NULL UNIQUE,
+                                 expires_at  TIMESTAMP WITH TIME ZONE NOT NULL,
+                                 used_at     TIMESTAMP WITH TIME ZONE,
+                                 created_at  TIMESTAMP WITH TIME ZONE DEFAULT now()
+);
+
+CREATE INDEX ON password_resets(user_id);
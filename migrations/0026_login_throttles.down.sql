@@ -0,0 +1,3 @@
+-- migrations/0026_login_throttles.down.sql
+
+DROP TABLE IF EXISTS login_throttles;
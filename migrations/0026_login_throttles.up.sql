@@ -0,0 +1,12 @@
+-- migrations/0026_login_throttles.up.sql
+
+-- Счётчики неудачных входов: key — "email:<адрес>" или "ip:<адрес>".
+-- Хранятся в БД, чтобы ограничения действовали после перезапуска и на всех экземплярах.
+CREATE TABLE login_throttles (
+                                 key              VARCHAR(320) PRIMARY KEY,
+                                 failures         INTEGER NOT NULL DEFAULT 0,
+                                 last_failure_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+                                 locked_until     TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX ON login_throttles(last_failure_at);
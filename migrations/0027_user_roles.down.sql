@@ -0,0 +1,3 @@
+-- migrations/0027_user_roles.down.sql
+
+ALTER TABLE users DROP COLUMN IF EXISTS role;
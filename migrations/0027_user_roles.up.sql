@@ -0,0 +1,6 @@
+-- migrations/0027_user_roles.up.sql
+
+-- Роль пользователя; попадает в access-токен. Операторы из OPERATOR_IDS
+-- получают роль при старте сервиса.
+ALTER TABLE users ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'customer'
+    CHECK (role IN ('customer', 'operator', 'risk_officer', 'admin'));
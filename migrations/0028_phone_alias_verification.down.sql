@@ -0,0 +1,7 @@
+-- migrations/0028_phone_alias_verification.down.sql
+
+ALTER TABLE phone_aliases
+    DROP COLUMN IF EXISTS code_attempts,
+    DROP COLUMN IF EXISTS code_expires_at,
+    DROP COLUMN IF EXISTS code_hash,
+    DROP COLUMN IF EXISTS verified_at;
@@ -0,0 +1,10 @@
+-- migrations/0028_phone_alias_verification.up.sql
+
+-- Телефон становится псевдонимом только после подтверждения кодом из SMS.
+-- Ранее зарегистрированные номера не подтверждены и должны быть подтверждены заново;
+-- неподтверждённую заявку на номер может перехватить другой пользователь.
+ALTER TABLE phone_aliases
+    ADD COLUMN verified_at      TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN code_hash        VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN code_expires_at  TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN code_attempts    INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+-- migrations/0029_hold_source.down.sql
+
+ALTER TABLE holds DROP COLUMN IF EXISTS source;
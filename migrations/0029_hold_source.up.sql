@@ -0,0 +1,9 @@
+-- migrations/0029_hold_source.up.sql
+
+-- Источник холда: 'customer' — поставлен владельцем счёта, 'card' — карточной
+-- авторизацией. Карточные холды списывает и снимает только эквайринг и шедулер.
+ALTER TABLE holds
+    ADD COLUMN source VARCHAR(20) NOT NULL DEFAULT 'customer';
+
+UPDATE holds h SET source = 'card'
+FROM card_transactions ct WHERE ct.hold_id = h.id;
@@ -0,0 +1,3 @@
+-- migrations/0030_transaction_direction.down.sql
+
+ALTER TABLE transactions DROP COLUMN IF EXISTS direction;
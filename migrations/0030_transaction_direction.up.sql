@@ -0,0 +1,16 @@
+-- migrations/0030_transaction_direction.up.sql
+
+-- Направление проводки: 'debit' — списание со счёта, 'credit' — зачисление.
+-- Раньше ноги перевода различались только префиксом описания "to:"/"from:".
+ALTER TABLE transactions
+    ADD COLUMN direction VARCHAR(6);
+
+UPDATE transactions SET direction = CASE
+    WHEN type IN ('deposit', 'reversal_in') THEN 'credit'
+    WHEN type = 'transfer' AND description LIKE 'from:%' THEN 'credit'
+    ELSE 'debit'
+END;
+
+ALTER TABLE transactions
+    ALTER COLUMN direction SET NOT NULL,
+    ADD CONSTRAINT transactions_direction_check CHECK (direction IN ('debit', 'credit'));
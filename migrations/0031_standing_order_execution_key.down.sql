@@ -0,0 +1,4 @@
+-- migrations/0031_standing_order_execution_key.down.sql
+
+DROP INDEX IF EXISTS standing_order_executions_success_key;
+ALTER TABLE standing_order_executions DROP COLUMN IF EXISTS scheduled_for;
@@ -0,0 +1,10 @@
+-- migrations/0031_standing_order_execution_key.up.sql
+
+-- Плановая дата исполнения: успешный платёж по поручению за одну дату может
+-- быть только один, повторный запуск после сбоя откатывается на индексе.
+-- У старых записей дата неизвестна и остаётся пустой.
+ALTER TABLE standing_order_executions
+    ADD COLUMN scheduled_for TIMESTAMP WITH TIME ZONE;
+
+CREATE UNIQUE INDEX standing_order_executions_success_key
+    ON standing_order_executions(order_id, scheduled_for) WHERE status = 'success';